@@ -108,3 +108,72 @@ func BenchmarkStackFormatting(b *testing.B) {
 	}
 	GlobalE = stackStr
 }
+
+func deepFieldsError(depth, fields int) error {
+	err := New("deep error")
+	for i := 0; i < depth; i++ {
+		f := make(F, fields)
+		for j := 0; j < fields; j++ {
+			f[fmt.Sprintf("key_%d_%d", i, j)] = j
+		}
+		err = WithFields(err, f)
+	}
+	return err
+}
+
+func BenchmarkWithFieldsDeep(b *testing.B) {
+	for _, depth := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("wrap-depth-%d", depth), func(b *testing.B) {
+			base := deepFieldsError(depth, 3)
+			f := F{"key": "value"}
+			b.ReportAllocs()
+			b.ResetTimer()
+			var err error
+			for i := 0; i < b.N; i++ {
+				err = WithFields(base, f)
+			}
+			b.StopTimer()
+			GlobalE = err
+		})
+		b.Run(fmt.Sprintf("fields-depth-%d", depth), func(b *testing.B) {
+			err := deepFieldsError(depth, 3)
+			b.ReportAllocs()
+			b.ResetTimer()
+			var fields F
+			for i := 0; i < b.N; i++ {
+				fields = Fields(err)
+			}
+			b.StopTimer()
+			GlobalE = fields
+		})
+	}
+}
+
+func BenchmarkFieldsArguments(b *testing.B) {
+	base := New("base error")
+	table, id := "users", 42
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		var err error
+		for i := 0; i < b.N; i++ {
+			err = WithMessage(base, "query", F{"table": table, "id": id})
+		}
+		GlobalE = err
+	})
+	b.Run("pairs", func(b *testing.B) {
+		b.ReportAllocs()
+		var err error
+		for i := 0; i < b.N; i++ {
+			err = WithMessage(base, "query", "table", table, "id", id)
+		}
+		GlobalE = err
+	})
+	b.Run("field-list", func(b *testing.B) {
+		b.ReportAllocs()
+		var err error
+		for i := 0; i < b.N; i++ {
+			err = WithFieldList(WithMessage(base, "query"), String("table", table), Int("id", id))
+		}
+		GlobalE = err
+	})
+}
@@ -0,0 +1,231 @@
+// Package catalog renders localized messages of errors, which are shown
+// to users instead of the public messages, see errors.PublicMessage.
+//
+// Messages are looked up by message IDs, the ID of an error is attached
+// by the field MessageID, or it is the code of the error, see
+// errors.CodeOf, or the kind of the error, see errors.KindOf, e.g.
+// "NotFound". A message may refer to the fields of
+// the error by "{key}", e.g. "user {user_id} not found".
+package catalog
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jxskiss/errors"
+)
+
+// MessageID is the field key of the message ID of an error, e.g.
+//
+//     err = errors.WithField(err, catalog.MessageID, "order.out_of_stock")
+var MessageID = errors.NewFieldKey[string]("message_id")
+
+// Catalog holds messages by locale and message ID, it is safe for
+// concurrent use.
+type Catalog struct {
+	// DefaultLocale is the last locale messages are looked up in.
+	DefaultLocale string
+
+	mu       sync.RWMutex
+	messages map[string]map[string]string // locale -> id -> message
+}
+
+// New returns an empty catalog with the given default locale.
+func New(defaultLocale string) *Catalog {
+	return &Catalog{
+		DefaultLocale: defaultLocale,
+		messages:      make(map[string]map[string]string),
+	}
+}
+
+// Set adds the messages of a locale to c, replacing the messages which
+// have the same IDs.
+func (c *Catalog) Set(locale string, messages map[string]string) {
+	locale = normalizeLocale(locale)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := c.messages[locale]
+	if m == nil {
+		m = make(map[string]string, len(messages))
+		c.messages[locale] = m
+	}
+	for id, msg := range messages {
+		m[id] = msg
+	}
+}
+
+// Lookup returns the message with the given ID, in the first of the given
+// locales which has it. The fallback chain of a locale has the locale
+// and its parents, e.g. "pt-BR" falls back to "pt", after the given
+// locales DefaultLocale is used.
+func (c *Catalog) Lookup(id string, locales ...string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, locale := range c.fallbacks(locales) {
+		if msg, ok := c.messages[locale][id]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// fallbacks returns the fallback chain of locales.
+func (c *Catalog) fallbacks(locales []string) []string {
+	var chain []string
+	add := func(locale string) {
+		for locale = normalizeLocale(locale); locale != ""; locale = parentLocale(locale) {
+			for _, l := range chain {
+				if l == locale {
+					return
+				}
+			}
+			chain = append(chain, locale)
+		}
+	}
+	for _, locale := range locales {
+		add(locale)
+	}
+	add(c.DefaultLocale)
+	return chain
+}
+
+// Message returns the message of err in the first of the given locales
+// which has it, with the fields of err substituted, see Lookup.
+//
+// The message is looked up by the ID attached by MessageID, then by the
+// code of err. If neither is found, the public message attached by
+// errors.WithPublicMessage is returned, since it is more specific than
+// the kind of err, else the message is looked up by the kind of err.
+// If that is not found either, Message returns the public message of
+// the kind, see errors.PublicMessage.
+// If err is nil, Message returns an empty string.
+func (c *Catalog) Message(err error, locales ...string) string {
+	if err == nil {
+		return ""
+	}
+	var ids []string
+	if id, ok := errors.FieldValue(err, MessageID); ok {
+		ids = append(ids, id)
+	}
+	if code := errors.CodeOf(err); code != "" {
+		ids = append(ids, code)
+	}
+	for _, id := range ids {
+		if msg, ok := c.Lookup(id, locales...); ok {
+			return expand(msg, errors.Fields(err))
+		}
+	}
+	if errors.HasPublicMessage(err) {
+		return errors.PublicMessage(err)
+	}
+	if kind := errors.KindOf(err); kind != "" {
+		if msg, ok := c.Lookup(kind, locales...); ok {
+			return expand(msg, errors.Fields(err))
+		}
+	}
+	return errors.PublicMessage(err)
+}
+
+// RequestMessage returns the message of err in the locales accepted by
+// the request, see AcceptLanguage. It can be used as the PublicMessage
+// of the httperr Mapper.
+func (c *Catalog) RequestMessage(r *http.Request, err error) string {
+	var locales []string
+	if r != nil {
+		locales = AcceptLanguage(r.Header.Get("Accept-Language"))
+	}
+	return c.Message(err, locales...)
+}
+
+// expand replaces "{key}" in msg by the value of the field key rendered
+// by errors.FormatValue, unknown keys are kept. "{{" and "}}" are replaced by "{" and "}".
+func expand(msg string, fields errors.F) string {
+	if !strings.ContainsAny(msg, "{}") {
+		return msg
+	}
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		ch := msg[i]
+		if (ch == '{' || ch == '}') && i+1 < len(msg) && msg[i+1] == ch {
+			b.WriteByte(ch)
+			i++
+			continue
+		}
+		if ch == '{' {
+			if end := strings.IndexByte(msg[i+1:], '}'); end >= 0 {
+				key := msg[i+1 : i+1+end]
+				if v, ok := fields[key]; ok {
+					b.WriteString(errors.FormatValue(v, errors.MaxFieldValueLen))
+					i += end + 1
+					continue
+				}
+			}
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+// AcceptLanguage returns the locales of an Accept-Language header, in the
+// order of preference, e.g. "fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5" returns
+// "fr-CH", "fr" and "en".
+func AcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var list []weighted
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		locale, params, _ := strings.Cut(part, ";")
+		locale = strings.TrimSpace(locale)
+		if locale == "" || locale == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			list = append(list, weighted{locale, q})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+	locales := make([]string, len(list))
+	for i, w := range list {
+		locales[i] = w.locale
+	}
+	return locales
+}
+
+// normalizeLocale returns locale with "_" replaced by "-", the language
+// in lower case and the region in upper case, e.g. "pt_br" returns
+// "pt-BR".
+func normalizeLocale(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	for i, p := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(p)
+		case len(p) == 2:
+			parts[i] = strings.ToUpper(p)
+		case len(p) == 4:
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// parentLocale returns the parent of locale, e.g. "pt" for "pt-BR", or an
+// empty string for a language.
+func parentLocale(locale string) string {
+	if i := strings.LastIndexByte(locale, '-'); i > 0 {
+		return locale[:i]
+	}
+	return ""
+}
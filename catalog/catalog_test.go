@@ -0,0 +1,113 @@
+package catalog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jxskiss/errors"
+	"github.com/jxskiss/errors/httperr"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCatalog() *Catalog {
+	c := New("en")
+	c.Set("en", map[string]string{
+		"NotFound":           "Not found",
+		"BadRequest":         "Invalid request",
+		"order.out_of_stock": "Only {available} of {item} left, {{sorry}}",
+	})
+	c.Set("pt", map[string]string{
+		"NotFound":           "Não encontrado",
+		"order.out_of_stock": "Restam apenas {available} de {item}",
+	})
+	c.Set("pt_br", map[string]string{
+		"NotFound": "Não encontrado (BR)",
+	})
+	return c
+}
+
+func Test_Lookup(t *testing.T) {
+	is := assert.New(t)
+	c := newCatalog()
+
+	msg, ok := c.Lookup("NotFound", "pt-BR")
+	is.True(ok)
+	is.Equal("Não encontrado (BR)", msg)
+	msg, _ = c.Lookup("order.out_of_stock", "pt-BR")
+	is.Equal("Restam apenas {available} de {item}", msg)
+	msg, _ = c.Lookup("BadRequest", "pt-BR")
+	is.Equal("Invalid request", msg)
+	msg, _ = c.Lookup("NotFound", "de", "pt")
+	is.Equal("Não encontrado", msg)
+	msg, _ = c.Lookup("NotFound")
+	is.Equal("Not found", msg)
+	_, ok = c.Lookup("Timeout", "pt")
+	is.False(ok)
+}
+
+func Test_Message(t *testing.T) {
+	is := assert.New(t)
+	c := newCatalog()
+
+	err := errors.WithField(errors.BadRequestf("order %d", 1), MessageID, "order.out_of_stock")
+	err = errors.With(err, "available", 2, "item", "socks")
+	is.Equal("Restam apenas 2 de socks", c.Message(err, "pt-PT"))
+	is.Equal("Only 2 of socks left, {sorry}", c.Message(err, "fr"))
+
+	// Fall back to the kind, then to the public message.
+	is.Equal("Não encontrado", c.Message(errors.Annotate(errors.NotFoundf("user"), "get user"), "pt"))
+	is.Equal("Invalid request", c.Message(errors.WithField(errors.BadRequestf("x"), MessageID, "unknown")))
+	is.Equal("timeout", c.Message(errors.Timeoutf("call"), "pt"))
+	is.Equal("try later", c.Message(errors.WithPublicMessage(errors.New("db down"), "try later")))
+	is.Equal("", c.Message(errors.New("db down")))
+	is.Equal("", c.Message(nil))
+
+	// The code of a definition takes precedence over the kind.
+	def := errors.Define(errors.Definition{Code: "CATALOG_GONE", Kind: "NotFound"})
+	c.Set("pt", map[string]string{"CATALOG_GONE": "Removido"})
+	is.Equal("Removido", c.Message(def.New("gone"), "pt"))
+	is.Equal("Not found", c.Message(def.New("gone"), "en"))
+
+	// An explicit public message takes precedence over the kind, but not
+	// over the message ID or the code.
+	err = errors.WithPublicMessage(errors.NotFoundf("user"), "The user was deleted.")
+	is.Equal("The user was deleted.", c.Message(err, "pt"))
+	is.Equal("Removido", c.Message(errors.WithPublicMessage(def.New("gone"), "Gone."), "pt"))
+	is.Equal("Invalid request", c.Message(errors.WithField(err, MessageID, "BadRequest")))
+}
+
+func Test_Expand(t *testing.T) {
+	is := assert.New(t)
+	fields := errors.F{"n": 3, "name": "alice"}
+	is.Equal("plain", expand("plain", fields))
+	is.Equal("3 items for alice", expand("{n} items for {name}", fields))
+	is.Equal("{missing} {n", expand("{missing} {n", fields))
+	is.Equal("{n} }", expand("{{n}} }", fields))
+
+	cyclic := map[string]interface{}{}
+	cyclic["self"] = cyclic
+	is.Equal("dump: map[self:<cycle>]", expand("dump: {dump}", errors.F{"dump": cyclic}))
+}
+
+func Test_AcceptLanguage(t *testing.T) {
+	is := assert.New(t)
+	is.Equal([]string{"fr-CH", "fr", "en"}, AcceptLanguage("fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5"))
+	is.Equal([]string{"en", "de"}, AcceptLanguage("de;q=0.5, en, ja;q=0"))
+	is.Empty(AcceptLanguage(""))
+}
+
+func Test_RequestMessage(t *testing.T) {
+	is := assert.New(t)
+	m := httperr.NewMapper()
+	m.PublicMessage = newCatalog().RequestMessage
+
+	r := httptest.NewRequest("GET", "/users/1", nil)
+	r.Header.Set("Accept-Language", "pt-BR,pt;q=0.9")
+	p := m.ToProblem(r, errors.NotFoundf("user 1"))
+	is.Equal(http.StatusNotFound, p.Status)
+	is.Equal("Não encontrado (BR)", p.Detail)
+
+	p = m.ToProblem(nil, errors.NotFoundf("user 1"))
+	is.Equal("Not found", p.Detail)
+}
@@ -0,0 +1,92 @@
+package catalog
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/jxskiss/errors"
+)
+
+// LoadFile loads the messages of a JSON or TOML file, see LoadFS.
+func (c *Catalog) LoadFile(name string) error {
+	dir, file := path.Split(strings.ReplaceAll(name, string(os.PathSeparator), "/"))
+	if dir == "" {
+		dir = "."
+	}
+	return c.load(os.DirFS(dir), file)
+}
+
+// LoadFS loads the messages of the files of fsys which match pattern,
+// in the syntax of fs.Glob, e.g. "locales/*.json".
+//
+// The locale of a file is its base name without the extension, e.g.
+// "pt-BR.json" holds the messages of the locale "pt-BR". Files with
+// the extension ".json" or ".toml" are decoded as JSON or TOML, other
+// files are ignored. Messages are strings keyed by the message IDs,
+// nested tables are flattened by joining the keys with ".", e.g.
+//
+//     NotFound = "Not found"
+//
+//     [order]
+//     out_of_stock = "Only {available} left in stock"
+//
+// defines the messages "NotFound" and "order.out_of_stock".
+func (c *Catalog) LoadFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return errors.Wrap(err, "catalog: glob", "pattern", pattern)
+	}
+	for _, name := range names {
+		if err := c.load(fsys, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) load(fsys fs.FS, name string) error {
+	ext := path.Ext(name)
+	if ext != ".json" && ext != ".toml" {
+		return nil
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return errors.Wrap(err, "catalog: read messages", "file", name)
+	}
+	var raw map[string]interface{}
+	if ext == ".json" {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		err = toml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return errors.Wrap(err, "catalog: decode messages", "file", name)
+	}
+	messages := make(map[string]string)
+	if err := flatten(messages, "", raw); err != nil {
+		return errors.Wrap(err, "catalog: decode messages", "file", name)
+	}
+	c.Set(strings.TrimSuffix(path.Base(name), ext), messages)
+	return nil
+}
+
+func flatten(messages map[string]string, prefix string, raw map[string]interface{}) error {
+	for k, v := range raw {
+		id := prefix + k
+		switch v := v.(type) {
+		case string:
+			messages[id] = v
+		case map[string]interface{}:
+			if err := flatten(messages, id+".", v); err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("message %q is not a string", id)
+		}
+	}
+	return nil
+}
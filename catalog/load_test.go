@@ -0,0 +1,46 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadFS(t *testing.T) {
+	is := assert.New(t)
+	fsys := fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{"NotFound": "Not found", "order": {"out_of_stock": "Out of stock"}}`)},
+		"locales/fr.toml": {Data: []byte("NotFound = \"Introuvable\"\n\n[order]\nout_of_stock = \"Rupture de stock\"\n")},
+		"locales/README":  {Data: []byte("not messages")},
+	}
+	c := New("en")
+	is.Nil(c.LoadFS(fsys, "locales/*"))
+
+	msg, _ := c.Lookup("order.out_of_stock", "fr")
+	is.Equal("Rupture de stock", msg)
+	msg, _ = c.Lookup("NotFound", "fr-FR")
+	is.Equal("Introuvable", msg)
+	msg, _ = c.Lookup("order.out_of_stock")
+	is.Equal("Out of stock", msg)
+
+	err := c.LoadFS(fstest.MapFS{"de.json": {Data: []byte(`{"NotFound": 1}`)}}, "*.json")
+	is.Contains(err.Error(), `message "NotFound" is not a string`)
+	err = c.LoadFS(fstest.MapFS{"de.toml": {Data: []byte(`NotFound = `)}}, "*.toml")
+	is.Contains(err.Error(), "catalog: decode messages")
+}
+
+func Test_LoadFile(t *testing.T) {
+	is := assert.New(t)
+	name := filepath.Join(t.TempDir(), "pt-BR.json")
+	is.Nil(os.WriteFile(name, []byte(`{"NotFound": "Não encontrado"}`), 0o644))
+
+	c := New("en")
+	is.Nil(c.LoadFile(name))
+	msg, ok := c.Lookup("NotFound", "pt-BR")
+	is.True(ok)
+	is.Equal("Não encontrado", msg)
+	is.NotNil(c.LoadFile(filepath.Join(t.TempDir(), "missing.json")))
+}
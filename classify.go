@@ -0,0 +1,97 @@
+package errors
+
+import (
+	"io/fs"
+	"sync"
+	"sync/atomic"
+)
+
+// Classifier returns the kind of an error which is not created by this
+// package, e.g. "Timeout" for context.DeadlineExceeded, or an empty
+// string if it does not recognize err. The kind is one of the names
+// reported by KindOf.
+//
+// A Classifier is called for each error in the chain, it should only
+// inspect err itself, not its causes.
+type Classifier func(err error) string
+
+var (
+	classifiersMu sync.Mutex
+	classifiers   atomic.Value // []Classifier
+)
+
+// RegisterClassifier registers fn to be consulted by KindOf and the IsX
+// functions, e.g. IsTimeout and IsNotFound, for errors which do not have
+// a kind given by this package. Classifiers are called in the order they
+// are registered, the first kind returned is used, the built-in rules for
+// the standard library are applied after all registered classifiers:
+//
+//   - errors which have a method Timeout() bool which returns true, e.g.
+//     context.DeadlineExceeded, os.ErrDeadlineExceeded and net.Error, are
+//     of the kind "Timeout"
+//   - fs.ErrNotExist is of the kind "NotFound"
+//   - fs.ErrExist is of the kind "AlreadyExists"
+//   - fs.ErrPermission is of the kind "Forbidden"
+//
+// Errors match fs.ErrNotExist and the others like errors.Is, e.g. the
+// syscall.Errno ENOENT in the chain of an *fs.PathError matches.
+//
+// It is usually called in an init function, but it is safe to be called
+// concurrently.
+func RegisterClassifier(fn Classifier) {
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+	old, _ := classifiers.Load().([]Classifier)
+	list := make([]Classifier, len(old), len(old)+1)
+	copy(list, old)
+	classifiers.Store(append(list, fn))
+}
+
+// classify returns the error type of err itself given by the registered
+// classifiers or the built-in rules.
+func classify(err error) (int, bool) {
+	list, _ := classifiers.Load().([]Classifier)
+	for _, fn := range list {
+		if kind := fn(err); kind != "" {
+			if etype, ok := kindType(kind); ok {
+				return etype, true
+			}
+		}
+	}
+	return classifyStd(err)
+}
+
+func classifyStd(err error) (int, bool) {
+	if te, ok := err.(interface{ Timeout() bool }); ok && te.Timeout() {
+		return timeout, true
+	}
+	switch {
+	case matches(err, fs.ErrNotExist):
+		return notFound, true
+	case matches(err, fs.ErrExist):
+		return alreadyExists, true
+	case matches(err, fs.ErrPermission):
+		return forbidden, true
+	}
+	return 0, false
+}
+
+// matches tells whether err itself matches target, like errors.Is without
+// unwrapping err.
+func matches(err, target error) bool {
+	if err == target {
+		return true
+	}
+	is, ok := err.(interface{ Is(error) bool })
+	return ok && is.Is(target)
+}
+
+// kindType returns the error type of the given kind name.
+func kindType(kind string) (int, bool) {
+	for etype, name := range kindNames {
+		if name == kind {
+			return etype, true
+		}
+	}
+	return 0, false
+}
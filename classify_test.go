@@ -0,0 +1,105 @@
+package errors
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "i/o timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+func TestClassifyStd(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	_, openErr := os.Open("/nonexistent/file")
+
+	tests := []struct {
+		err  error
+		kind string
+	}{
+		{ctx.Err(), "Timeout"},
+		{context.DeadlineExceeded, "Timeout"},
+		{os.ErrDeadlineExceeded, "Timeout"},
+		{timeoutError{}, "Timeout"},
+		{os.ErrNotExist, "NotFound"},
+		{openErr, "NotFound"},
+		{fs.ErrExist, "AlreadyExists"},
+		{fs.ErrPermission, "Forbidden"},
+		{&fs.PathError{Op: "open", Path: "x", Err: fs.ErrPermission}, "Forbidden"},
+		{Wrap(os.ErrNotExist, "read config"), "NotFound"},
+		{context.Canceled, ""},
+		{New("plain"), ""},
+	}
+	for _, tt := range tests {
+		if got := KindOf(tt.err); got != tt.kind {
+			t.Errorf("KindOf(%v): got %q, want %q", tt.err, got, tt.kind)
+		}
+	}
+	if !IsTimeout(Annotate(ctx.Err(), "call")) {
+		t.Errorf("IsTimeout: got false, want true")
+	}
+	if !IsNotFound(openErr) {
+		t.Errorf("IsNotFound: got false, want true")
+	}
+
+	// A kind given by this package takes precedence.
+	err := WithKind(Wrap(os.ErrNotExist, "read config"), "BadRequest")
+	if got := KindOf(err); got != "BadRequest" {
+		t.Errorf("KindOf: got %q, want %q", got, "BadRequest")
+	}
+	err = Annotate(WithKind(os.ErrNotExist, "BadRequest"), "read config")
+	if IsNotFound(err) {
+		t.Errorf("IsNotFound: got true, want false")
+	}
+}
+
+type codeError struct{ code int }
+
+func (e codeError) Error() string { return "code error" }
+
+func TestRegisterClassifier(t *testing.T) {
+	defer classifiers.Store([]Classifier(nil))
+
+	RegisterClassifier(func(err error) string {
+		if e, ok := err.(codeError); ok && e.code == 404 {
+			return "NotFound"
+		}
+		return ""
+	})
+	RegisterClassifier(func(err error) string {
+		if _, ok := err.(codeError); ok {
+			return "Unknown"
+		}
+		return ""
+	})
+	RegisterClassifier(func(err error) string {
+		if _, ok := err.(codeError); ok {
+			return "BadRequest"
+		}
+		return ""
+	})
+	RegisterClassifier(func(err error) string {
+		if err == fs.ErrExist {
+			return "BadRequest"
+		}
+		return ""
+	})
+
+	if !IsNotFound(Wrap(codeError{404}, "get")) {
+		t.Errorf("IsNotFound: got false, want true")
+	}
+	// Unknown kind names are ignored.
+	if got := KindOf(codeError{400}); got != "BadRequest" {
+		t.Errorf("KindOf: got %q, want %q", got, "BadRequest")
+	}
+	// Registered classifiers are consulted before the built-in rules.
+	if got := KindOf(fs.ErrExist); got != "BadRequest" {
+		t.Errorf("KindOf: got %q, want %q", got, "BadRequest")
+	}
+}
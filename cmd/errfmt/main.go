@@ -0,0 +1,160 @@
+// Command errfmt pretty-prints error dumps produced by formatting errors
+// of package github.com/jxskiss/errors with the "%+v" verb.
+//
+// Input is read from the files given as arguments, or stdin if there is
+// none. By default the whole input is treated as one error dump, with
+// -json each input line is decoded as a JSON log entry, and the dump is
+// taken from the value of -key, lines which are not JSON or don't have
+// the key are copied to output unchanged.
+//
+// Usage:
+//
+//     errfmt [flags] [file ...]
+//     kubectl logs my-pod | errfmt -json -key stacktrace
+//
+// Flags:
+//
+//     -json       read input as JSON log lines
+//     -key        dotted path of the error dump in JSON log lines (default "error")
+//     -color      colorize output: auto, always or never (default "auto")
+//     -hide       regexp of function names to hide from stack traces
+//     -trim       comma separated file path prefixes to trim
+//     -collapse   collapse frames shown by an earlier stack trace (default true)
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const defaultHide = `^(runtime|testing)\.`
+
+func main() {
+	var (
+		jsonMode = flag.Bool("json", false, "read input as JSON log lines")
+		key      = flag.String("key", "error", "dotted path of the error dump in JSON log lines")
+		color    = flag.String("color", "auto", "colorize output: auto, always or never")
+		hide     = flag.String("hide", defaultHide, "regexp of function names to hide from stack traces")
+		trim     = flag.String("trim", "", "comma separated file path prefixes to trim")
+		collapse = flag.Bool("collapse", true, "collapse frames shown by an earlier stack trace")
+	)
+	flag.Parse()
+
+	r := &renderer{
+		collapse: *collapse,
+	}
+	switch *color {
+	case "always":
+		r.color = true
+	case "never":
+		r.color = false
+	case "auto":
+		r.color = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	default:
+		fatalf("invalid -color value %q", *color)
+	}
+	if *hide != "" {
+		re, err := regexp.Compile(*hide)
+		if err != nil {
+			fatalf("invalid -hide regexp: %v", err)
+		}
+		r.hide = re
+	}
+	if *trim != "" {
+		r.trim = strings.Split(*trim, ",")
+	}
+
+	inputs := []io.Reader{os.Stdin}
+	if flag.NArg() > 0 {
+		inputs = inputs[:0]
+		for _, name := range flag.Args() {
+			f, err := os.Open(name)
+			if err != nil {
+				fatalf("%v", err)
+			}
+			defer f.Close()
+			inputs = append(inputs, f)
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	for _, in := range inputs {
+		var err error
+		if *jsonMode {
+			err = processJSON(out, in, r, strings.Split(*key, "."))
+		} else {
+			err = processText(out, in, r)
+		}
+		if err != nil {
+			out.Flush()
+			fatalf("%v", err)
+		}
+	}
+}
+
+func processText(w io.Writer, in io.Reader, r *renderer) error {
+	text, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	r.render(w, parseDump(string(text)))
+	return nil
+}
+
+func processJSON(w io.Writer, in io.Reader, r *renderer, keyPath []string) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		text, ok := lookupJSON(line, keyPath)
+		if !ok {
+			w.Write(line)
+			io.WriteString(w, "\n")
+			continue
+		}
+		r.render(w, parseDump(text))
+		io.WriteString(w, "\n")
+	}
+	return scanner.Err()
+}
+
+// lookupJSON decodes line as a JSON object and returns the string value
+// at the given key path.
+func lookupJSON(line []byte, keyPath []string) (string, bool) {
+	var obj interface{}
+	if err := json.Unmarshal(line, &obj); err != nil {
+		return "", false
+	}
+	for _, key := range keyPath {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if obj, ok = m[key]; !ok {
+			return "", false
+		}
+	}
+	text, ok := obj.(string)
+	return text, ok
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "errfmt: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The following prefixes and separators mirror the output produced by
+// the "%+v" verb of the errors package, see formatMultiLine in multi.go,
+// withFields.Format in fields.go, message.formatTemplate in errors.go
+// and withViolations.Format in violation.go.
+const (
+	multilinePrefix    = "the following errors occurred:"
+	multilineSeparator = " -  "
+	multilineIndent    = "    "
+	contextPrefix      = "context:"
+	templatePrefix     = "template:"
+	violationPrefix    = "violation:"
+)
+
+var fileLineRE = regexp.MustCompile(`^\t(.*):(\d+)$`)
+
+type nodeKind int
+
+const (
+	messageNode nodeKind = iota
+	frameNode
+	contextNode
+	templateNode
+	violationNode
+	groupNode
+)
+
+// node is a single element of a parsed error dump.
+type node struct {
+	kind nodeKind
+
+	// text is the message line, the raw key/value pairs of a context line,
+	// the format specifier and arguments of a template line, or the field
+	// and description of a violation line.
+	text string
+
+	// function, file and line are set for frame nodes.
+	function string
+	file     string
+	line     int
+
+	// items are the member dumps of a MultiError group.
+	items []*dump
+}
+
+// dump is a parsed "%+v" error dump, a flat list of messages, stack frames
+// and context lines in the order they were printed.
+type dump struct {
+	nodes []*node
+}
+
+// parseDump parses text produced by formatting an error with "%+v".
+// Lines which are not recognized are kept as message lines, thus parsing
+// never fails.
+func parseDump(text string) *dump {
+	text = strings.TrimRight(text, "\n")
+	return parseLines(strings.Split(text, "\n"))
+}
+
+func parseLines(lines []string) *dump {
+	d := &dump{}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// A stack frame is printed as "function\n\tfile:line".
+		if i+1 < len(lines) && line != "" && !isSpace(line[0]) {
+			if m := fileLineRE.FindStringSubmatch(lines[i+1]); m != nil {
+				lineNo, _ := strconv.Atoi(m[2])
+				d.nodes = append(d.nodes, &node{
+					kind:     frameNode,
+					function: line,
+					file:     m[1],
+					line:     lineNo,
+				})
+				i++
+				continue
+			}
+		}
+
+		if line == multilinePrefix {
+			group := &node{kind: groupNode, text: line}
+			var item []string
+			for i+1 < len(lines) {
+				next := lines[i+1]
+				if strings.HasPrefix(next, multilineSeparator) {
+					if item != nil {
+						group.items = append(group.items, parseLines(item))
+					}
+					item = []string{next[len(multilineSeparator):]}
+				} else if item != nil && strings.HasPrefix(next, multilineIndent) {
+					item = append(item, next[len(multilineIndent):])
+				} else {
+					break
+				}
+				i++
+			}
+			if item != nil {
+				group.items = append(group.items, parseLines(item))
+			}
+			d.nodes = append(d.nodes, group)
+			continue
+		}
+
+		if strings.HasPrefix(line, contextPrefix) {
+			d.nodes = append(d.nodes, &node{
+				kind: contextNode,
+				text: strings.TrimSpace(line[len(contextPrefix):]),
+			})
+			continue
+		}
+
+		if strings.HasPrefix(line, templatePrefix) {
+			d.nodes = append(d.nodes, &node{
+				kind: templateNode,
+				text: strings.TrimSpace(line[len(templatePrefix):]),
+			})
+			continue
+		}
+
+		if strings.HasPrefix(line, violationPrefix) {
+			d.nodes = append(d.nodes, &node{
+				kind: violationNode,
+				text: strings.TrimSpace(line[len(violationPrefix):]),
+			})
+			continue
+		}
+
+		d.nodes = append(d.nodes, &node{kind: messageNode, text: line})
+	}
+	return d
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// splitContext splits the key/value pairs of a context line, quoted values
+// are unquoted.
+func splitContext(text string) (pairs [][2]string) {
+	for len(text) > 0 {
+		text = strings.TrimLeft(text, " ")
+		eq := strings.IndexByte(text, '=')
+		if eq < 0 {
+			break
+		}
+		key := text[:eq]
+		text = text[eq+1:]
+		var value string
+		if strings.HasPrefix(text, `"`) {
+			if quoted, err := strconv.QuotedPrefix(text); err == nil {
+				value, _ = strconv.Unquote(quoted)
+				text = text[len(quoted):]
+				pairs = append(pairs, [2]string{key, value})
+				continue
+			}
+		}
+		if sp := strings.IndexByte(text, ' '); sp >= 0 {
+			value, text = text[:sp], text[sp:]
+		} else {
+			value, text = text, ""
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return pairs
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func kinds(d *dump) []nodeKind {
+	out := make([]nodeKind, 0, len(d.nodes))
+	for _, n := range d.nodes {
+		out = append(out, n.kind)
+	}
+	return out
+}
+
+func firstFrame(d *dump) *node {
+	for _, n := range d.nodes {
+		if n.kind == frameNode {
+			return n
+		}
+	}
+	return nil
+}
+
+func TestParseFundamental(t *testing.T) {
+	is := assert.New(t)
+	d := parseDump(fmt.Sprintf("%+v", errors.New("something broke")))
+
+	is.Equal(messageNode, d.nodes[0].kind)
+	is.Equal("something broke", d.nodes[0].text)
+	f := firstFrame(d)
+	is.NotNil(f)
+	is.Equal("github.com/jxskiss/errors/cmd/errfmt.TestParseFundamental", f.function)
+	is.True(strings.HasSuffix(f.file, "parse_test.go"))
+	is.True(f.line > 0)
+	for _, n := range d.nodes[1:] {
+		is.Equal(frameNode, n.kind)
+	}
+}
+
+func TestParseWrapAndFields(t *testing.T) {
+	is := assert.New(t)
+	err := errors.WithStack(io.EOF)
+	err = errors.WithMessage(err, "read body")
+	err = errors.WithFields(err, errors.F{"user": "alice", "note": "two words"})
+	d := parseDump(fmt.Sprintf("%+v", err))
+
+	is.Equal(messageNode, d.nodes[0].kind)
+	is.Equal("EOF", d.nodes[0].text)
+	is.Equal(frameNode, d.nodes[1].kind)
+
+	last := d.nodes[len(d.nodes)-1]
+	is.Equal(contextNode, last.kind)
+	is.Equal([][2]string{{"note", "two words"}, {"user", "alice"}}, splitContext(last.text))
+
+	msg := d.nodes[len(d.nodes)-2]
+	is.Equal(messageNode, msg.kind)
+	is.Equal("read body", msg.text)
+}
+
+func TestParseTemplate(t *testing.T) {
+	is := assert.New(t)
+	err := errors.Wrapf(errors.NotFoundf("user %d", 42), "load %s", "profile")
+	d := parseDump(fmt.Sprintf("%+v", err))
+
+	is.Equal("user 42 not found", d.nodes[0].text)
+	is.Equal(templateNode, d.nodes[1].kind)
+	is.Equal(`"user %d not found" args: [42]`, d.nodes[1].text)
+	is.Equal(frameNode, d.nodes[2].kind)
+
+	last := len(d.nodes) - 1
+	is.Equal("load profile", d.nodes[last-1].text)
+	is.Equal(templateNode, d.nodes[last].kind)
+}
+
+func TestParseViolations(t *testing.T) {
+	is := assert.New(t)
+	err := errors.WithViolations(errors.BadRequestf("order"),
+		errors.FieldViolation{Field: "items[0].qty", Description: "must be positive"})
+	d := parseDump(fmt.Sprintf("%+v", err))
+
+	last := d.nodes[len(d.nodes)-1]
+	is.Equal(violationNode, last.kind)
+	is.Equal("items[0].qty: must be positive", last.text)
+
+	var b bytes.Buffer
+	r := &renderer{color: true}
+	r.render(&b, d)
+	is.Contains(b.String(), colorFaint+"violation:"+colorReset+" "+colorYellow+"items[0].qty"+colorReset+": must be positive\n")
+}
+
+func TestParseMultiError(t *testing.T) {
+	is := assert.New(t)
+	var err error
+	err = errors.Append(err, errors.New("first"), io.EOF)
+	err = errors.Append(err, errors.Append(nil, io.ErrUnexpectedEOF, io.ErrShortWrite))
+	d := parseDump(fmt.Sprintf("%+v", errors.WithMessage(err, "batch failed")))
+
+	is.Equal([]nodeKind{groupNode, messageNode}, kinds(d))
+	group := d.nodes[0]
+	is.Len(group.items, 4)
+	is.Equal("first", group.items[0].nodes[0].text)
+	is.NotNil(firstFrame(group.items[0]))
+	is.Equal([]nodeKind{messageNode}, kinds(group.items[1]))
+	is.Equal("EOF", group.items[1].nodes[0].text)
+	is.Equal("batch failed", d.nodes[1].text)
+}
+
+func TestParseNestedMultiError(t *testing.T) {
+	is := assert.New(t)
+	inner := errors.Append(nil, io.EOF, io.ErrUnexpectedEOF)
+	outer := errors.MultiError{errors.New("first"), errors.WithMessage(inner, "inner")}
+	d := parseDump(fmt.Sprintf("%+v", outer))
+
+	is.Equal([]nodeKind{groupNode}, kinds(d))
+	is.Len(d.nodes[0].items, 2)
+	second := d.nodes[0].items[1]
+	is.Equal([]nodeKind{groupNode, messageNode}, kinds(second))
+	is.Len(second.nodes[0].items, 2)
+	is.Equal("unexpected EOF", second.nodes[0].items[1].nodes[0].text)
+}
+
+func TestRender(t *testing.T) {
+	is := assert.New(t)
+	err := func() error { return errors.New("root cause") }()
+	err = errors.WithStack(err)
+	d := parseDump(fmt.Sprintf("%+v", err))
+
+	var b bytes.Buffer
+	r := &renderer{
+		hide:     regexp.MustCompile(defaultHide),
+		collapse: true,
+	}
+	r.render(&b, d)
+	out := b.String()
+	is.True(strings.HasPrefix(out, "root cause\n    at github.com/jxskiss/errors/cmd/errfmt.TestRender"))
+	is.Contains(out, "frames hidden")
+	is.Contains(out, "frames shown above")
+	is.NotContains(out, "\x1b[")
+
+	b.Reset()
+	r.color = true
+	r.render(&b, d)
+	is.Contains(b.String(), colorBoldRed+"root cause"+colorReset)
+}
+
+func TestTrimPath(t *testing.T) {
+	is := assert.New(t)
+	r := &renderer{}
+	is.Equal("github.com/pkg/errors@v0.9.1/stack.go",
+		r.trimPath("/home/u/go/pkg/mod/github.com/pkg/errors@v0.9.1/stack.go"))
+	is.Equal("net/http/server.go", r.trimPath("/usr/local/go/src/net/http/server.go"))
+	is.Equal("/srv/app/main.go", r.trimPath("/srv/app/main.go"))
+
+	r.trim = []string{"/srv/app"}
+	is.Equal("main.go", r.trimPath("/srv/app/main.go"))
+}
+
+func TestLookupJSON(t *testing.T) {
+	is := assert.New(t)
+	line := []byte(`{"level":"error","err":{"dump":"oops\nmain.main\n\t/app/main.go:10"}}`)
+	text, ok := lookupJSON(line, []string{"err", "dump"})
+	is.True(ok)
+	is.Equal("oops\nmain.main\n\t/app/main.go:10", text)
+
+	_, ok = lookupJSON(line, []string{"error"})
+	is.False(ok)
+	_, ok = lookupJSON([]byte("plain text line"), []string{"error"})
+	is.False(ok)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ANSI escape sequences used when colour output is enabled.
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorYellow  = "\x1b[33m"
+	colorCyan    = "\x1b[36m"
+	colorFaint   = "\x1b[2m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+type renderer struct {
+	color    bool
+	hide     *regexp.Regexp
+	trim     []string
+	collapse bool
+
+	// seen records frames already printed for the current dump,
+	// used to collapse stack frames which are shared by several
+	// layers of the error chain.
+	seen map[string]bool
+}
+
+func (r *renderer) render(w io.Writer, d *dump) {
+	r.seen = make(map[string]bool)
+	r.renderDump(w, d, "")
+}
+
+func (r *renderer) renderDump(w io.Writer, d *dump, indent string) {
+	for i := 0; i < len(d.nodes); i++ {
+		n := d.nodes[i]
+		switch n.kind {
+		case messageNode:
+			fmt.Fprintf(w, "%s%s\n", indent, r.paint(colorBoldRed, n.text))
+		case contextNode:
+			r.renderContext(w, n, indent)
+		case templateNode:
+			fmt.Fprintf(w, "%s%s\n", indent, r.paint(colorFaint, templatePrefix+" "+n.text))
+		case violationNode:
+			r.renderViolation(w, n, indent)
+		case groupNode:
+			fmt.Fprintf(w, "%s%s\n", indent, r.paint(colorBold, n.text))
+			for _, item := range n.items {
+				fmt.Fprintf(w, "%s%s\n", indent, r.paint(colorBold, " -"))
+				r.renderDump(w, item, indent+multilineIndent)
+			}
+		case frameNode:
+			j := i
+			for j < len(d.nodes) && d.nodes[j].kind == frameNode {
+				j++
+			}
+			r.renderFrames(w, d.nodes[i:j], indent)
+			i = j - 1
+		}
+	}
+}
+
+func (r *renderer) renderContext(w io.Writer, n *node, indent string) {
+	fmt.Fprintf(w, "%s%s", indent, r.paint(colorFaint, contextPrefix))
+	for _, kv := range splitContext(n.text) {
+		value := kv[1]
+		if strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(w, " %s=%s", r.paint(colorYellow, kv[0]), value)
+	}
+	fmt.Fprintln(w)
+}
+
+// renderViolation highlights the field of a violation line, which is
+// printed as "field: description".
+func (r *renderer) renderViolation(w io.Writer, n *node, indent string) {
+	fmt.Fprintf(w, "%s%s ", indent, r.paint(colorFaint, violationPrefix))
+	if i := strings.Index(n.text, ": "); i >= 0 {
+		fmt.Fprintf(w, "%s%s\n", r.paint(colorYellow, n.text[:i]), n.text[i:])
+		return
+	}
+	fmt.Fprintln(w, n.text)
+}
+
+func (r *renderer) renderFrames(w io.Writer, frames []*node, indent string) {
+	var hidden, shown int
+	flush := func() {
+		if hidden > 0 {
+			fmt.Fprintf(w, "%s    %s\n", indent, r.paint(colorFaint, fmt.Sprintf("... %d frames hidden", hidden)))
+			hidden = 0
+		}
+		if shown > 0 {
+			fmt.Fprintf(w, "%s    %s\n", indent, r.paint(colorFaint, fmt.Sprintf("... %d frames shown above", shown)))
+			shown = 0
+		}
+	}
+	for i := 0; i < len(frames); i++ {
+		f := frames[i]
+		key := fmt.Sprintf("%s %s:%d", f.function, f.file, f.line)
+		if r.collapse && r.seen[key] {
+			shown++
+			continue
+		}
+		r.seen[key] = true
+		if r.hide != nil && r.hide.MatchString(f.function) {
+			hidden++
+			continue
+		}
+		flush()
+
+		repeat := 0
+		for r.collapse && i+1 < len(frames) && sameFrame(f, frames[i+1]) {
+			repeat++
+			i++
+		}
+		fmt.Fprintf(w, "%s    at %s (%s)", indent,
+			r.paint(colorCyan, f.function),
+			r.paint(colorFaint, fmt.Sprintf("%s:%d", r.trimPath(f.file), f.line)))
+		if repeat > 0 {
+			fmt.Fprintf(w, " %s", r.paint(colorYellow, fmt.Sprintf("(repeated %d more times)", repeat)))
+		}
+		fmt.Fprintln(w)
+	}
+	flush()
+}
+
+func sameFrame(a, b *node) bool {
+	return a.function == b.function && a.file == b.file && a.line == b.line
+}
+
+// trimPath removes the configured prefixes from file. Without configured
+// prefixes, the module cache and GOPATH/GOROOT source directories are
+// removed.
+func (r *renderer) trimPath(file string) string {
+	if len(r.trim) > 0 {
+		for _, prefix := range r.trim {
+			if strings.HasPrefix(file, prefix) {
+				return strings.TrimPrefix(file[len(prefix):], "/")
+			}
+		}
+		return file
+	}
+	for _, sep := range []string{"/pkg/mod/", "/go/src/"} {
+		if i := strings.LastIndex(file, sep); i >= 0 {
+			return file[i+len(sep):]
+		}
+	}
+	return file
+}
+
+func (r *renderer) paint(color, text string) string {
+	if !r.color || text == "" {
+		return text
+	}
+	return color + text + colorReset
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/jxskiss/errors"
+)
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{
+	"quote":   strconv.Quote,
+	"comment": comment,
+}).Parse(`// Code generated by errgen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/jxskiss/errors"
+
+{{range .Errors}}
+{{- if .Doc}}{{comment (print .DefName " is the definition of " .Code ". " .Doc)}}{{else}}// {{.DefName}} is the definition of {{.Code}}.
+{{end -}}
+var {{.DefName}} = errors.Define(errors.Definition{
+	Code: {{quote .Code}},
+{{- if .Kind}}
+	Kind: {{quote .Kind}},
+{{- end}}
+{{- if .HTTPStatus}}
+	HTTPStatus: {{.HTTPStatus}},
+{{- end}}
+{{- if .GRPCCode}}
+	GRPCCode: {{quote .GRPCCode}},
+{{- end}}
+{{- if .PublicMessage}}
+	PublicMessage: {{quote .PublicMessage}},
+{{- end}}
+{{- if .Retryable}}
+	Retryable: true,
+{{- end}}
+{{- if .Doc}}
+	Doc: {{quote .Doc}},
+{{- end}}
+})
+
+// New{{.Name}} returns an error of {{.DefName}}.
+{{- if .Params}}
+// The parameters are attached to the error as fields.
+{{- end}}
+func New{{.Name}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}} {{$p.Type}}{{end}}) error {
+{{- if not .Message}}
+	return {{.DefName}}.NewSkip(1, {{quote .GoMessage}}{{range .Params}}, {{quote .Name}}, {{.Name}}{{end}})
+{{- else if .Params}}
+	return errors.With({{.DefName}}.NewfSkip(1, {{quote .Message}}{{if .HasVerbs}}{{range .Params}}, {{.Name}}{{end}}{{end}}){{range .Params}}, {{quote .Name}}, {{.Name}}{{end}})
+{{- else}}
+	return {{.DefName}}.NewfSkip(1, {{quote .Message}})
+{{- end}}
+}
+
+// Is{{.Name}} tells whether err is an error of {{.DefName}}.
+func Is{{.Name}}(err error) bool {
+	return {{.DefName}}.Is(err)
+}
+{{end}}`))
+
+// DefName returns the name of the variable of the definition of e.
+func (e *errorDef) DefName() string {
+	return e.Name + "Def"
+}
+
+// GoMessage returns the message of the constructor of e, it defaults to
+// the public message, or the code.
+func (e *errorDef) GoMessage() string {
+	switch {
+	case e.Message != "":
+		return e.Message
+	case e.PublicMessage != "":
+		return e.PublicMessage
+	}
+	return e.Code
+}
+
+// comment renders text as a line comment wrapped at 76 columns.
+func comment(text string) string {
+	var b strings.Builder
+	line := "//"
+	for _, word := range strings.Fields(text) {
+		if len(line) > 2 && len(line)+1+len(word) > 76 {
+			b.WriteString(line + "\n")
+			line = "//"
+		}
+		line += " " + word
+	}
+	b.WriteString(line + "\n")
+	return b.String()
+}
+
+// generateGo returns the formatted Go source of spec, source is the path
+// of the spec recorded by the header.
+func generateGo(s *spec, source string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := goTemplate.Execute(&buf, struct {
+		*spec
+		Source string
+	}{s, filepath.Base(source)})
+	if err != nil {
+		return nil, errors.Wrap(err, "execute template")
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "format generated code")
+	}
+	return code, nil
+}
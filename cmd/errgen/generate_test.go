@@ -0,0 +1,161 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpec = `
+package: orders
+errors:
+  - name: OutOfStock
+    code: ORDER_OUT_OF_STOCK
+    kind: BadRequest
+    http_status: 409
+    grpc_code: FailedPrecondition
+    message: "only %d of %s left"
+    params:
+      - {name: available, type: int}
+      - {name: item, type: string}
+    public_message: "The item is out of stock."
+    doc: The item cannot be ordered in the requested quantity.
+  - name: PaymentUnavailable
+    code: ORDER_PAYMENT_UNAVAILABLE
+    kind: Timeout
+    retryable: true
+  - name: OrderLocked
+    code: ORDER_LOCKED
+    message: "100%% locked"
+    params:
+      - {name: order, type: int64}
+`
+
+func TestParseSpec(t *testing.T) {
+	is := assert.New(t)
+	s, err := parseSpec([]byte(testSpec))
+	if !is.Nil(err) {
+		return
+	}
+	is.Equal("orders", s.Package)
+	is.Len(s.Errors, 3)
+	is.Equal(409, s.Errors[0].HTTPStatus)
+	is.Equal([]param{{"available", "int"}, {"item", "string"}}, s.Errors[0].Params)
+	is.True(s.Errors[1].Retryable)
+
+	// JSON is a subset of YAML.
+	s, err = parseSpec([]byte(`{"errors": [{"name": "Gone", "code": "GONE", "kind": "NotFound"}]}`))
+	is.Nil(err)
+	is.Equal("GONE", s.Errors[0].Code)
+	is.Equal("GONE", s.Errors[0].GoMessage())
+
+	bad := []struct {
+		spec string
+		want string
+	}{
+		{`errors: []`, "no error is defined"},
+		{`errors: [{name: gone, code: GONE}]`, "not an exported identifier"},
+		{`errors: [{name: Gone}]`, "invalid code"},
+		{`errors: [{name: Gone, code: GONE, kind: Missing}]`, `unknown kind "Missing"`},
+		{`errors: [{name: Gone, code: GONE, http_status: 200}]`, "invalid http_status 200"},
+		{`errors: [{name: Gone, code: GONE, grpc_code: Gone}]`, `unknown grpc_code "Gone"`},
+		{`errors: [{name: Gone, code: GONE, params: [{name: type, type: int}]}]`, `invalid param name "type"`},
+		{`errors: [{name: Gone, code: GONE, params: [{name: errors, type: int}]}]`, `param name "errors" shadows`},
+		{`errors: [{name: Gone, code: GONE, params: [{name: GoneDef, type: int}]}]`, `param name "GoneDef" shadows`},
+		{`errors: [{name: Gone, code: GONE, message: "%s %d", params: [{name: id, type: int}]}]`, "message has 2 verbs but 1 params"},
+		{`errors: [{name: Gone, code: GONE, message: "%d"}]`, "message has 1 verbs but 0 params"},
+		{`errors: [{name: Gone, code: GONE, message: "%[1]d", params: [{name: id, type: int}]}]`, "argument indexes are not supported"},
+		{`errors: [{name: Gone, code: GONE}, {name: Lost, code: GONE}]`, `duplicate error code "GONE"`},
+		{`errors: [{name: Gone, code: GONE, status: 410}]`, "field status not found"},
+	}
+	for _, c := range bad {
+		_, err := parseSpec([]byte(c.spec))
+		if is.NotNil(err, c.spec) {
+			is.Contains(err.Error(), c.want)
+		}
+	}
+}
+
+func TestGenerateGo(t *testing.T) {
+	is := assert.New(t)
+	s, _ := parseSpec([]byte(testSpec))
+	code, err := generateGo(s, "testdata/errors.yaml")
+	if !is.Nil(err) {
+		return
+	}
+	got := string(code)
+	for _, want := range []string{
+		"// Code generated by errgen from errors.yaml. DO NOT EDIT.\n",
+		"package orders\n",
+		"var OutOfStockDef = errors.Define(errors.Definition{\n",
+		"\tHTTPStatus:    409,\n",
+		"\tGRPCCode:      \"FailedPrecondition\",\n",
+		"func NewOutOfStock(available int, item string) error {\n",
+		"errors.With(OutOfStockDef.NewfSkip(1, \"only %d of %s left\", available, item), \"available\", available, \"item\", item)",
+		"func IsOutOfStock(err error) bool {\n",
+		"\tRetryable: true,\n",
+		"func NewPaymentUnavailable() error {\n\treturn PaymentUnavailableDef.NewSkip(1, \"ORDER_PAYMENT_UNAVAILABLE\")\n",
+		"return errors.With(OrderLockedDef.NewfSkip(1, \"100%% locked\"), \"order\", order)\n",
+	} {
+		is.Contains(got, want)
+	}
+
+	// The generated code compiles against this module.
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "errors_gen.go", code, 0)
+	if !is.Nil(err) {
+		return
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("orders", fset, []*ast.File{file}, nil)
+	if !is.Nil(err) {
+		return
+	}
+	for _, name := range []string{"OutOfStockDef", "NewOutOfStock", "IsOutOfStock", "NewOrderLocked"} {
+		is.NotNil(pkg.Scope().Lookup(name), name)
+	}
+}
+
+func TestCountVerbs(t *testing.T) {
+	is := assert.New(t)
+	for format, want := range map[string]int{
+		"":               0,
+		"100%% sure":     0,
+		"%d of %s":       2,
+		"%-5.2f %+v %#x": 3,
+		"%*d %.*s":       4,
+		"%v%%%v":         2,
+	} {
+		got, err := countVerbs(format)
+		is.Nil(err, format)
+		is.Equal(want, got, format)
+	}
+	_, err := countVerbs("50%")
+	is.NotNil(err)
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	is := assert.New(t)
+	s, _ := parseSpec([]byte(testSpec))
+	got := string(generateMarkdown(s))
+	is.True(strings.HasPrefix(got, "# Errors of package orders\n"))
+	is.Contains(got, "| [`ORDER_OUT_OF_STOCK`](#order_out_of_stock) | OutOfStock | BadRequest | 409 | FailedPrecondition | no | The item is out of stock. |\n")
+	is.Contains(got, "| [`ORDER_PAYMENT_UNAVAILABLE`](#order_payment_unavailable) | PaymentUnavailable | Timeout | - | - | yes | - |\n")
+	is.Contains(got, "\n## ORDER_OUT_OF_STOCK\n\nThe item cannot be ordered in the requested quantity.\n\n")
+	is.Contains(got, "- Constructor: `NewOutOfStock(available int, item string)`\n")
+}
+
+func TestComment(t *testing.T) {
+	is := assert.New(t)
+	got := comment(strings.Repeat("word ", 20))
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+		is.True(strings.HasPrefix(line, "// "))
+		is.LessOrEqual(len(line), 76)
+	}
+}
@@ -0,0 +1,91 @@
+// Command errgen generates Go code and documentation for the errors of
+// a package from a spec, so that every team defines its errors the same
+// way.
+//
+// For each error of the spec errgen generates a definition registered by
+// errors.Define, e.g. OutOfStockDef, a typed constructor, e.g.
+// NewOutOfStock, whose parameters are attached as fields, and a predicate,
+// e.g. IsOutOfStock. The message is a format specifier, if it has verbs,
+// their number must match the number of parameters, which are formatted
+// in order. If the message is empty, the public message or the code is
+// used as the message as is.
+// A markdown catalog of the errors is written if -doc is given.
+//
+// The spec is YAML or JSON:
+//
+//     package: orders
+//     errors:
+//       - name: OutOfStock
+//         code: ORDER_OUT_OF_STOCK
+//         kind: BadRequest
+//         http_status: 409
+//         grpc_code: FailedPrecondition
+//         message: "only %d of %s left"
+//         params:
+//           - {name: available, type: int}
+//           - {name: item, type: string}
+//         public_message: "The item is out of stock."
+//         retryable: false
+//         doc: The item cannot be ordered in the requested quantity.
+//
+// Only name and code are required. The package defaults to $GOPACKAGE,
+// which is set by go generate.
+//
+// Usage:
+//
+//     //go:generate go run github.com/jxskiss/errors/cmd/errgen -spec errors.yaml -doc ERRORS.md
+//
+// Flags:
+//
+//     -spec   path of the spec (default "errors.yaml")
+//     -out    path of the generated Go file (default "errors_gen.go")
+//     -doc    path of the generated markdown catalog, none if empty
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	var (
+		specPath = flag.String("spec", "errors.yaml", "path of the spec")
+		outPath  = flag.String("out", "errors_gen.go", "path of the generated Go file")
+		docPath  = flag.String("doc", "", "path of the generated markdown catalog")
+	)
+	flag.Parse()
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	spec, err := parseSpec(data)
+	if err != nil {
+		fatalf("%s: %v", *specPath, err)
+	}
+	if spec.Package == "" {
+		spec.Package = os.Getenv("GOPACKAGE")
+	}
+	if spec.Package == "" {
+		fatalf("%s: package is required", *specPath)
+	}
+
+	code, err := generateGo(spec, *specPath)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*outPath, code, 0o644); err != nil {
+		fatalf("%v", err)
+	}
+	if *docPath != "" {
+		if err := os.WriteFile(*docPath, generateMarkdown(spec), 0o644); err != nil {
+			fatalf("%v", err)
+		}
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "errgen: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// generateMarkdown returns a markdown catalog of the errors of spec,
+// which is a summary table followed by a section for each error.
+func generateMarkdown(s *spec) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Errors of package %s\n\n", s.Package)
+	b.WriteString("| Code | Name | Kind | HTTP | gRPC | Retryable | Public message |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, e := range s.Errors {
+		fmt.Fprintf(&b, "| [`%s`](#%s) | %s | %s | %s | %s | %s | %s |\n",
+			e.Code, anchor(e.Code), e.Name, cell(e.Kind), cell(httpStatus(e)),
+			cell(e.GRPCCode), yesNo(e.Retryable), cell(e.PublicMessage))
+	}
+	for _, e := range s.Errors {
+		fmt.Fprintf(&b, "\n## %s\n\n", e.Code)
+		if e.Doc != "" {
+			b.WriteString(strings.TrimSpace(e.Doc) + "\n\n")
+		}
+		fmt.Fprintf(&b, "- Constructor: `New%s(%s)`\n", e.Name, paramList(e.Params))
+		fmt.Fprintf(&b, "- Predicate: `Is%s(err)`\n", e.Name)
+		fmt.Fprintf(&b, "- Message: `%s`\n", e.GoMessage())
+	}
+	return b.Bytes()
+}
+
+func httpStatus(e *errorDef) string {
+	if e.HTTPStatus == 0 {
+		return ""
+	}
+	return fmt.Sprint(e.HTTPStatus)
+}
+
+func paramList(params []param) string {
+	list := make([]string, len(params))
+	for i, p := range params {
+		list[i] = p.Name + " " + p.Type
+	}
+	return strings.Join(list, ", ")
+}
+
+// anchor returns the anchor of a heading as generated by GitHub.
+func anchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// cell escapes text for a table cell, empty cells are rendered as "-".
+func cell(text string) string {
+	if text == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"go/token"
+	"regexp"
+	"strings"
+
+	"github.com/jxskiss/errors"
+	"go.yaml.in/yaml/v3"
+)
+
+// spec is the definition of the errors of a package.
+type spec struct {
+	Package string      `yaml:"package"`
+	Errors  []*errorDef `yaml:"errors"`
+}
+
+// errorDef is the definition of an error.
+type errorDef struct {
+	Name          string  `yaml:"name"`
+	Code          string  `yaml:"code"`
+	Kind          string  `yaml:"kind"`
+	HTTPStatus    int     `yaml:"http_status"`
+	GRPCCode      string  `yaml:"grpc_code"`
+	Message       string  `yaml:"message"`
+	Params        []param `yaml:"params"`
+	PublicMessage string  `yaml:"public_message"`
+	Retryable     bool    `yaml:"retryable"`
+	Doc           string  `yaml:"doc"`
+}
+
+// param is a parameter of the constructor of an error, it is attached to
+// the error as a field.
+type param struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+}
+
+var (
+	exportedRE = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+	codeRE     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.\-]*$`)
+)
+
+// parseSpec parses a YAML or JSON spec and validates it.
+func parseSpec(data []byte) (*spec, error) {
+	s := &spec{}
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(s); err != nil {
+		return nil, errors.Wrap(err, "parse spec")
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *spec) validate() error {
+	if s.Package != "" && !token.IsIdentifier(s.Package) {
+		return errors.Errorf("invalid package name %q", s.Package)
+	}
+	if len(s.Errors) == 0 {
+		return errors.New("no error is defined")
+	}
+	names := make(map[string]bool)
+	codes := make(map[string]bool)
+	for i, e := range s.Errors {
+		if err := e.validate(); err != nil {
+			return errors.WithMessagef(err, "errors[%d]", i)
+		}
+		if names[e.Name] {
+			return errors.Errorf("duplicate error name %q", e.Name)
+		}
+		if codes[e.Code] {
+			return errors.Errorf("duplicate error code %q", e.Code)
+		}
+		names[e.Name], codes[e.Code] = true, true
+	}
+	return nil
+}
+
+func (e *errorDef) validate() error {
+	if !exportedRE.MatchString(e.Name) {
+		return errors.Errorf("name %q is not an exported identifier", e.Name)
+	}
+	if !codeRE.MatchString(e.Code) {
+		return errors.Errorf("%s: invalid code %q", e.Name, e.Code)
+	}
+	if e.Kind != "" && !validKind(e.Kind) {
+		return errors.Errorf("%s: unknown kind %q, must be one of %s",
+			e.Name, e.Kind, strings.Join(errors.Kinds(), ", "))
+	}
+	if e.HTTPStatus != 0 && (e.HTTPStatus < 400 || e.HTTPStatus > 599) {
+		return errors.Errorf("%s: invalid http_status %d", e.Name, e.HTTPStatus)
+	}
+	if e.GRPCCode != "" && !validGRPCCode(e.GRPCCode) {
+		return errors.Errorf("%s: unknown grpc_code %q", e.Name, e.GRPCCode)
+	}
+	seen := make(map[string]bool)
+	for _, p := range e.Params {
+		if !token.IsIdentifier(p.Name) || token.IsKeyword(p.Name) || p.Name == "_" {
+			return errors.Errorf("%s: invalid param name %q", e.Name, p.Name)
+		}
+		if p.Name == "errors" || p.Name == e.DefName() {
+			return errors.Errorf("%s: param name %q shadows an identifier used by the constructor", e.Name, p.Name)
+		}
+		if p.Type == "" {
+			return errors.Errorf("%s: param %q has no type", e.Name, p.Name)
+		}
+		if seen[p.Name] {
+			return errors.Errorf("%s: duplicate param %q", e.Name, p.Name)
+		}
+		seen[p.Name] = true
+	}
+	if e.Message != "" {
+		verbs, err := countVerbs(e.Message)
+		if err != nil {
+			return errors.WithMessagef(err, "%s: message", e.Name)
+		}
+		if verbs > 0 && verbs != len(e.Params) {
+			return errors.Errorf("%s: message has %d verbs but %d params", e.Name, verbs, len(e.Params))
+		}
+	}
+	return nil
+}
+
+// HasVerbs tells whether the message of e has verbs, which are formatted
+// with the params, else the params are only attached as fields.
+func (e *errorDef) HasVerbs() bool {
+	verbs, _ := countVerbs(e.Message)
+	return verbs > 0
+}
+
+// countVerbs returns the number of arguments consumed by the verbs of
+// a format specifier, "%%" consumes none and "*" consumes one.
+func countVerbs(format string) (int, error) {
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for ; i < len(format) && strings.IndexByte("+-# 0123456789.*", format[i]) >= 0; i++ {
+			if format[i] == '*' {
+				n++
+			}
+		}
+		switch {
+		case i == len(format):
+			return 0, errors.New("missing verb at end of format")
+		case format[i] == '[':
+			return 0, errors.New("explicit argument indexes are not supported")
+		case format[i] != '%':
+			n++
+		}
+	}
+	return n, nil
+}
+
+func validKind(kind string) bool {
+	for _, k := range errors.Kinds() {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// grpcCodes are the names of the gRPC status codes.
+var grpcCodes = []string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded",
+	"NotFound", "AlreadyExists", "PermissionDenied", "ResourceExhausted",
+	"FailedPrecondition", "Aborted", "OutOfRange", "Unimplemented",
+	"Internal", "Unavailable", "DataLoss", "Unauthenticated",
+}
+
+func validGRPCCode(name string) bool {
+	for _, c := range grpcCodes {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Command errsym symbolizes raw stacks which are logged in the compact
+// form produced by errors.RawStack, the output has the same format as
+// the stack trace printed by "%+v", thus can be piped into errfmt.
+//
+// The binary given by -binary must be the exact binary that produced
+// the stacks, which is verified by the Go build ID.
+// Only ELF binaries are supported. Inlined calls are reported as part
+// of the function they were inlined into.
+//
+// Encoded stacks are read from the arguments, or searched in each line
+// of stdin if there is no argument, any other text is copied to output
+// unchanged.
+//
+// Usage:
+//
+//     errsym -binary ./server v1:<build id>:<anchor>:<offsets>
+//     grep raw_stack app.log | errsym -binary ./server
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/jxskiss/errors"
+)
+
+var rawStackRE = regexp.MustCompile(`v1:[A-Za-z0-9_\-/+=.]*:[0-9a-f]+:-?[0-9a-f]+(,-?[0-9a-f]+)*`)
+
+func main() {
+	var (
+		binary = flag.String("binary", "", "path of the binary which produced the stacks")
+		force  = flag.Bool("force", false, "symbolize even if the build ID does not match")
+	)
+	flag.Parse()
+	if *binary == "" {
+		fatalf("-binary is required")
+	}
+
+	sym, err := newSymbolizer(*binary)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	if flag.NArg() > 0 {
+		for _, arg := range flag.Args() {
+			if err := writeStack(out, sym, arg, *force); err != nil {
+				out.Flush()
+				fatalf("%v", err)
+			}
+			io.WriteString(out, "\n")
+		}
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		last := 0
+		for _, loc := range rawStackRE.FindAllIndex(line, -1) {
+			out.Write(line[last:loc[0]])
+			if err := writeStack(out, sym, string(line[loc[0]:loc[1]]), *force); err != nil {
+				fmt.Fprintf(os.Stderr, "errsym: %v\n", err)
+				out.Write(line[loc[0]:loc[1]])
+			}
+			last = loc[1]
+		}
+		out.Write(line[last:])
+		out.Write([]byte("\n"))
+	}
+	if err := scanner.Err(); err != nil {
+		out.Flush()
+		fatalf("%v", err)
+	}
+}
+
+func writeStack(w io.Writer, sym *symbolizer, encoded string, force bool) error {
+	r, err := errors.ParseRawStack(encoded)
+	if err != nil {
+		return err
+	}
+	frames, err := sym.symbolize(r, force)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	for _, f := range frames {
+		b.WriteByte('\n')
+		f.Format(&b)
+	}
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "errsym: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"debug/elf"
+	"debug/gosym"
+	"fmt"
+	"io"
+
+	"github.com/jxskiss/errors"
+)
+
+// anchorSymbol is the function which errors.RawStack encodes program
+// counters relative to.
+const anchorSymbol = "github.com/jxskiss/errors.stackAnchor"
+
+// frame is a symbolized stack frame.
+type frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Format prints the frame the same way as "%+v" of errors.Frame does.
+func (f frame) Format(w io.Writer) {
+	fmt.Fprintf(w, "%s\n\t%s:%d", f.Function, f.File, f.Line)
+}
+
+// symbolizer resolves program counters using the symbol and line tables
+// of a Go ELF binary.
+type symbolizer struct {
+	buildID string
+	anchor  uint64
+	table   *gosym.Table
+}
+
+func newSymbolizer(path string) (*symbolizer, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open binary")
+	}
+	defer f.Close()
+
+	text := f.Section(".text")
+	pclntab := f.Section(".gopclntab")
+	if text == nil || pclntab == nil {
+		return nil, errors.Errorf("%s: no Go line table found", path)
+	}
+	pclnData, err := pclntab.Data()
+	if err != nil {
+		return nil, errors.Wrap(err, "read .gopclntab")
+	}
+	var symData []byte
+	if symtab := f.Section(".gosymtab"); symtab != nil {
+		if symData, err = symtab.Data(); err != nil {
+			return nil, errors.Wrap(err, "read .gosymtab")
+		}
+	}
+	table, err := gosym.NewTable(symData, gosym.NewLineTable(pclnData, text.Addr))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse symbol table")
+	}
+	anchor := table.LookupFunc(anchorSymbol)
+	if anchor == nil {
+		return nil, errors.Errorf("%s: symbol %s not found, the binary does not use package errors", path, anchorSymbol)
+	}
+
+	// A missing build ID is not fatal, the caller decides whether
+	// to accept stacks without verification.
+	buildID, _ := errors.ReadBuildID(path)
+	return &symbolizer{
+		buildID: buildID,
+		anchor:  anchor.Entry,
+		table:   table,
+	}, nil
+}
+
+// symbolize resolves the program counters of r, it fails if the build ID
+// of r does not match the binary's, unless force is true.
+func (s *symbolizer) symbolize(r *errors.RawStack, force bool) ([]frame, error) {
+	if !force && r.BuildID != s.buildID {
+		return nil, errors.Errorf("build ID mismatch: stack %q, binary %q", r.BuildID, s.buildID)
+	}
+	frames := make([]frame, 0, len(r.PCs))
+	for _, pc := range r.PCs {
+		// Convert the runtime address to an address in the binary file.
+		addr := s.anchor + uint64(pc-r.Anchor)
+
+		// The program counters are return addresses, look up the
+		// call instruction instead, like runtime.CallersFrames does.
+		file, line, fn := s.table.PCToLine(addr - 1)
+		if fn == nil {
+			frames = append(frames, frame{Function: "unknown", File: "unknown"})
+			continue
+		}
+		frames = append(frames, frame{Function: fn.Name, File: file, Line: line})
+	}
+	return frames, nil
+}
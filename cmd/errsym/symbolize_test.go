@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+//go:noinline
+func makeError() (error, int) {
+	_, _, line, _ := runtime.Caller(0)
+	return errors.New("boom"), line + 1
+}
+
+func TestSymbolize(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("symbolization requires an ELF binary")
+	}
+	is := assert.New(t)
+	err, line := makeError()
+
+	raw := errors.GetRawStack(err)
+	is.NotNil(raw)
+	is.NotEmpty(raw.BuildID)
+
+	encoded := raw.String()
+	is.True(rawStackRE.MatchString(encoded))
+	parsed, perr := errors.ParseRawStack(encoded)
+	is.Nil(perr)
+	is.Equal(raw, parsed)
+
+	exe, _ := os.Executable()
+	sym, serr := newSymbolizer(exe)
+	if !is.Nil(serr) {
+		return
+	}
+	frames, serr := sym.symbolize(parsed, false)
+	is.Nil(serr)
+	is.Equal(len(raw.PCs), len(frames))
+	is.Equal("github.com/jxskiss/errors/cmd/errsym.makeError", frames[0].Function)
+	is.True(strings.HasSuffix(frames[0].File, "symbolize_test.go"))
+	is.Equal(line, frames[0].Line)
+	is.Equal("github.com/jxskiss/errors/cmd/errsym.TestSymbolize", frames[1].Function)
+
+	parsed.BuildID = "other"
+	_, serr = sym.symbolize(parsed, false)
+	is.NotNil(serr)
+	_, serr = sym.symbolize(parsed, true)
+	is.Nil(serr)
+}
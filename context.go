@@ -0,0 +1,144 @@
+package errors
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type contextFieldsKey struct{}
+
+// ContextWithFields returns a copy of ctx which carries the given fields,
+// in addition to the fields already carried by ctx. If a key is already
+// carried by ctx, the new value replaces the old one.
+//
+// The fields carried by a context are attached to the errors created by
+// NewCtx, WrapCtx and WithStackCtx. Request-scoped data, e.g. the request
+// ID or the tenant, can be attached once when the request comes in:
+//
+//     ctx = errors.ContextWithFields(ctx, errors.F{"request_id": reqID})
+//     ...
+//     return errors.WrapCtx(ctx, err, "query user")
+func ContextWithFields(ctx context.Context, fields F) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	old, _ := ctx.Value(contextFieldsKey{}).([]Field)
+	list := make([]Field, len(old), len(old)+len(fields))
+	copy(list, old)
+	list = appendMap(list, fields)
+	return context.WithValue(ctx, contextFieldsKey{}, list)
+}
+
+// FieldsFromContext returns the fields carried by ctx, including the
+// fields returned by the registered ContextExtractor functions.
+// If there is no field, it returns nil.
+func FieldsFromContext(ctx context.Context) F {
+	list := contextFields(ctx)
+	if len(list) == 0 {
+		return nil
+	}
+	fields := make(F, len(list))
+	for _, f := range list {
+		fields[f.Key] = f.Value()
+	}
+	return fields
+}
+
+// ContextExtractor returns fields from a context, e.g. the trace ID and
+// span ID of an OpenTelemetry span carried by the context.
+type ContextExtractor func(ctx context.Context) []Field
+
+var (
+	extractorsMu      sync.Mutex
+	contextExtractors atomic.Value // []ContextExtractor
+)
+
+// RegisterContextExtractor registers fn to be called by NewCtx, WrapCtx,
+// WithStackCtx and FieldsFromContext, the returned fields are attached
+// to the errors in addition to the fields carried by the context.
+// Fields carried by the context take precedence over extracted fields
+// with the same key.
+//
+// It is usually called in an init function, but it is safe to be called
+// concurrently.
+func RegisterContextExtractor(fn ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	old, _ := contextExtractors.Load().([]ContextExtractor)
+	list := make([]ContextExtractor, len(old), len(old)+1)
+	copy(list, old)
+	contextExtractors.Store(append(list, fn))
+}
+
+// contextFields returns the extracted fields followed by the fields
+// carried by ctx.
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	var list []Field
+	extractors, _ := contextExtractors.Load().([]ContextExtractor)
+	for _, fn := range extractors {
+		for _, f := range fn(ctx) {
+			list = appendField(list, f)
+		}
+	}
+	carried, _ := ctx.Value(contextFieldsKey{}).([]Field)
+	if list == nil {
+		return carried
+	}
+	for _, f := range carried {
+		list = appendField(list, f)
+	}
+	return list
+}
+
+// withContext attaches the fields of ctx, followed by the given fields,
+// to err as a single layer.
+func withContext(ctx context.Context, err error, fields []interface{}) error {
+	if list := contextFields(ctx); len(list) > 0 {
+		fields = append([]interface{}{list}, fields...)
+	}
+	if len(fields) > 0 {
+		err = With(err, fields...)
+	}
+	return err
+}
+
+// NewCtx is like New, and also attaches the fields carried by ctx,
+// see ContextWithFields.
+// The given fields take precedence over the fields carried by ctx.
+func NewCtx(ctx context.Context, message string, fields ...interface{}) error {
+	err := &fundamental{
+		msg:   plainMessage(message),
+		stack: callersSkip(3),
+	}
+	return withContext(ctx, err, fields)
+}
+
+// WrapCtx is like Wrap, and also attaches the fields carried by ctx,
+// see ContextWithFields.
+// The given fields take precedence over the fields carried by ctx.
+// If err is nil, WrapCtx returns nil.
+func WrapCtx(ctx context.Context, err error, message string, fields ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return withContext(ctx, wrap(err, message, 4), fields)
+}
+
+// WithStackCtx is like WithStack, and also attaches the fields carried
+// by ctx, see ContextWithFields.
+// The given fields take precedence over the fields carried by ctx.
+// If err is nil, WithStackCtx returns nil.
+func WithStackCtx(ctx context.Context, err error, fields ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	err = &withStack{
+		error: err,
+		stack: callersSkip(3),
+	}
+	return withContext(ctx, err, fields)
+}
@@ -0,0 +1,89 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWithFields(t *testing.T) {
+	is := assert.New(t)
+
+	ctx := context.Background()
+	is.Nil(FieldsFromContext(ctx))
+	is.Equal(ctx, ContextWithFields(ctx, nil))
+
+	ctx1 := ContextWithFields(ctx, F{"request_id": "r1", "tenant": "t1"})
+	ctx2 := ContextWithFields(ctx1, F{"tenant": "t2", "user": 7})
+	is.Equal(F{"request_id": "r1", "tenant": "t1"}, FieldsFromContext(ctx1))
+	is.Equal(F{"request_id": "r1", "tenant": "t2", "user": 7}, FieldsFromContext(ctx2))
+
+	err := NewCtx(ctx2, "failed", "user", 8)
+	is.Equal("failed", err.Error())
+	is.Equal(F{"request_id": "r1", "tenant": "t2", "user": 8}, Fields(err))
+	is.Equal("context: request_id=r1 tenant=t2 user=8", lastLine(fmt.Sprintf("%+v", err)))
+
+	err = WrapCtx(ctx1, io.EOF, "read", Int("n", 1))
+	is.Equal("read: EOF", err.Error())
+	is.Equal(F{"request_id": "r1", "tenant": "t1", "n": 1}, Fields(err))
+
+	err = WithStackCtx(ctx1, io.EOF)
+	is.Equal(io.EOF, Cause(err))
+	is.Equal(F{"request_id": "r1", "tenant": "t1"}, Fields(err))
+
+	// No field carried by the context.
+	err = WrapCtx(ctx, io.EOF, "read")
+	is.Nil(Fields(err))
+
+	is.Nil(WrapCtx(ctx1, nil, "read"))
+	is.Nil(WithStackCtx(ctx1, nil))
+}
+
+func TestContextStack(t *testing.T) {
+	ctx := ContextWithFields(context.Background(), F{"k": "v"})
+	for _, err := range []error{
+		NewCtx(ctx, "new"),
+		WrapCtx(ctx, io.EOF, "wrap"),
+		WithStackCtx(ctx, io.EOF),
+	} {
+		frame := GetStackTracer(err).StackTrace()[0]
+		if got := fmt.Sprintf("%n", frame); got != "TestContextStack" {
+			t.Errorf("%v: first frame = %s, want TestContextStack", err, got)
+		}
+	}
+}
+
+type traceIDKey struct{}
+
+func TestContextExtractor(t *testing.T) {
+	is := assert.New(t)
+	defer contextExtractors.Store([]ContextExtractor(nil))
+
+	RegisterContextExtractor(func(ctx context.Context) []Field {
+		if id, ok := ctx.Value(traceIDKey{}).(string); ok {
+			return []Field{String("trace_id", id), String("tenant", "default")}
+		}
+		return nil
+	})
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "abc")
+	is.Equal(F{"trace_id": "abc", "tenant": "default"}, FieldsFromContext(ctx))
+
+	ctx = ContextWithFields(ctx, F{"tenant": "t1"})
+	err := WrapCtx(ctx, io.EOF, "read")
+	is.Equal(F{"trace_id": "abc", "tenant": "t1"}, Fields(err))
+
+	is.Nil(FieldsFromContext(context.Background()))
+}
+
+func lastLine(s string) string {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '\n' {
+			return s[i+1:]
+		}
+	}
+	return s
+}
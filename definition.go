@@ -0,0 +1,185 @@
+package errors
+
+import (
+	"sort"
+	"sync"
+)
+
+// Definition describes a class of errors identified by a code, e.g.
+// "ORDER_OUT_OF_STOCK". Definitions are usually generated from a spec
+// by the command errgen, which also generates typed constructors and
+// predicates, and a markdown catalog of the errors.
+type Definition struct {
+	// Code identifies the errors, it is unique in a process.
+	Code string
+
+	// Kind is the kind of the errors, see KindOf.
+	Kind string
+
+	// HTTPStatus is the status of the errors translated by package
+	// httperr, if it is zero, the status is mapped from the kind.
+	HTTPStatus int
+
+	// GRPCCode is the name of the gRPC status code of the errors
+	// translated by package grpc_ext, e.g. "FailedPrecondition", if it
+	// is empty, the code is mapped from the kind.
+	GRPCCode string
+
+	// PublicMessage is the public message of the errors, see
+	// PublicMessage.
+	PublicMessage string
+
+	// Retryable tells whether the errors are retryable, see IsRetryable.
+	Retryable bool
+
+	// Doc documents the errors.
+	Doc string
+}
+
+var (
+	definitionsMu sync.RWMutex
+	definitions   = make(map[string]*Definition)
+)
+
+// Define registers d by its code, the returned definition is used to
+// create errors of the code. Define panics if the code is empty or
+// already defined, it should be called to initialize package variables.
+//
+//     var OutOfStockDef = errors.Define(errors.Definition{
+//             Code: "ORDER_OUT_OF_STOCK",
+//             Kind: "BadRequest",
+//     })
+func Define(d Definition) *Definition {
+	if d.Code == "" {
+		panic("errors: Define: empty code")
+	}
+	definitionsMu.Lock()
+	defer definitionsMu.Unlock()
+	if _, ok := definitions[d.Code]; ok {
+		panic("errors: Define: code " + d.Code + " is already defined")
+	}
+	def := &d
+	definitions[d.Code] = def
+	return def
+}
+
+// LookupDefinition returns the definition registered for code.
+func LookupDefinition(code string) (*Definition, bool) {
+	definitionsMu.RLock()
+	defer definitionsMu.RUnlock()
+	def, ok := definitions[code]
+	return def, ok
+}
+
+// Definitions returns the registered definitions ordered by code.
+func Definitions() []*Definition {
+	definitionsMu.RLock()
+	list := make([]*Definition, 0, len(definitions))
+	for _, def := range definitions {
+		list = append(list, def)
+	}
+	definitionsMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// New returns an error of d with the supplied message, it records the
+// stack trace at the point it was called, and attaches the fields like
+// New of this package.
+func (d *Definition) New(message string, fields ...interface{}) error {
+	return d.NewSkip(1, message, fields...)
+}
+
+// Newf returns an error of d with the message formatted according to
+// a format specifier, it records the stack trace at the point it was
+// called.
+func (d *Definition) Newf(format string, args ...interface{}) error {
+	return d.NewfSkip(1, format, args...)
+}
+
+// NewSkip is like New, but it skips the given number of stack frames
+// above its caller when recording the stack trace, e.g. a constructor
+// which creates errors of d passes 1 to record the stack trace at the
+// point the constructor was called.
+func (d *Definition) NewSkip(skip int, message string, fields ...interface{}) error {
+	var err error
+	err = &fundamental{
+		msg:   plainMessage(message),
+		stack: callersSkip(skip + 3),
+	}
+	if len(fields) > 0 {
+		err = With(err, fields...)
+	}
+	return d.annotate(err)
+}
+
+// NewfSkip is like Newf, but it skips the given number of stack frames
+// above its caller when recording the stack trace, see NewSkip.
+func (d *Definition) NewfSkip(skip int, format string, args ...interface{}) error {
+	return d.annotate(&fundamental{
+		msg:   formatMessage(format, args),
+		stack: callersSkip(skip + 3),
+	})
+}
+
+// Wrap annotates err as an error of d, e.g. to restore an error received
+// from another process. The kind of d is attached by WithKind, and
+// the public message and the retryable mark of d are attached if given.
+//
+// If err is nil, Wrap returns nil.
+func (d *Definition) Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return d.annotate(err)
+}
+
+func (d *Definition) annotate(err error) error {
+	if d.Kind != "" {
+		err = WithKind(err, d.Kind)
+	}
+	if d.PublicMessage != "" {
+		err = WithPublicMessage(err, d.PublicMessage)
+	}
+	if d.Retryable {
+		err = Retryable(err, 0)
+	}
+	return &withCode{annotation: newAnnotation(err), def: d}
+}
+
+// Is tells whether err is an error of d, that is an error in the chain of
+// err has the code of d.
+func (d *Definition) Is(err error) bool {
+	for e := err; e != nil; e = Unwrap(e) {
+		if w, ok := e.(*withCode); ok && w.def.Code == d.Code {
+			return true
+		}
+	}
+	return false
+}
+
+// withCode annotates an error with a definition, see Definition.
+type withCode struct {
+	annotation
+	def *Definition
+}
+
+// DefinitionOf returns the definition of the outermost error in the chain
+// of err created by a Definition.
+func DefinitionOf(err error) (*Definition, bool) {
+	for e := err; e != nil; e = Unwrap(e) {
+		if w, ok := e.(*withCode); ok {
+			return w.def, true
+		}
+	}
+	return nil, false
+}
+
+// CodeOf returns the code of err, see DefinitionOf, or an empty string if
+// err is not created by a Definition.
+func CodeOf(err error) string {
+	if def, ok := DefinitionOf(err); ok {
+		return def.Code
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var testDefinition = Define(Definition{
+	Code:          "TEST_OUT_OF_STOCK",
+	Kind:          "BadRequest",
+	HTTPStatus:    409,
+	PublicMessage: "out of stock",
+	Retryable:     true,
+})
+
+var otherDefinition = Define(Definition{Code: "TEST_ORDER_FAILED"})
+
+func TestDefinition(t *testing.T) {
+	err := testDefinition.Newf("only %d left", 3)
+	if err.Error() != "only 3 left" || !HasStack(err) {
+		t.Errorf("Newf: got %q", err)
+	}
+	if !testDefinition.Is(err) || !testDefinition.Is(Annotate(err, "order")) {
+		t.Errorf("Is: got false")
+	}
+	if testDefinition.Is(New("only 3 left")) {
+		t.Errorf("Is: got true for a plain error")
+	}
+	if !testDefinition.Is(otherDefinition.Wrap(err)) || !otherDefinition.Is(otherDefinition.Wrap(err)) {
+		t.Errorf("Is: inner code is not found")
+	}
+	if got := CodeOf(Annotate(err, "order")); got != "TEST_OUT_OF_STOCK" {
+		t.Errorf("CodeOf: got %q", got)
+	}
+	if def, ok := DefinitionOf(err); !ok || def != testDefinition {
+		t.Errorf("DefinitionOf: got %v, %v", def, ok)
+	}
+	if KindOf(err) != "BadRequest" || PublicMessage(err) != "out of stock" || !IsRetryable(err) {
+		t.Errorf("annotations: got %q, %q, %v", KindOf(err), PublicMessage(err), IsRetryable(err))
+	}
+	if !strings.Contains(fmt.Sprintf("%+v", err), "TestDefinition") {
+		t.Errorf("Format: stack trace is missing: %+v", err)
+	}
+
+	err = testDefinition.New("out of stock", "item", "book")
+	if Fields(err)["item"] != "book" {
+		t.Errorf("New: fields: got %v", Fields(err))
+	}
+	data, _ := MarshalJSON(err)
+	if !strings.Contains(string(data), `"code":"TEST_OUT_OF_STOCK"`) {
+		t.Errorf("JSON: got %s", data)
+	}
+
+	if testDefinition.Wrap(nil) != nil {
+		t.Errorf("Wrap(nil): got non-nil error")
+	}
+	if CodeOf(testDefinition.Wrap(fmt.Errorf("remote"))) != "TEST_OUT_OF_STOCK" || CodeOf(nil) != "" {
+		t.Errorf("Wrap: code is lost")
+	}
+}
+
+// newOutOfStock is a constructor like those generated by errgen.
+func newOutOfStock(available int) error {
+	return With(testDefinition.NewfSkip(1, "only %d left", available), "available", available)
+}
+
+func TestDefinitionSkip(t *testing.T) {
+	for name, err := range map[string]error{
+		"NewfSkip": newOutOfStock(3),
+		"NewSkip":  func() error { return testDefinition.NewSkip(1, "out of stock") }(),
+	} {
+		st := GetStackTracer(err).StackTrace()
+		if got := fmt.Sprintf("%n", st[0]); got != "TestDefinitionSkip" {
+			t.Errorf("%s: got stack trace from %s", name, got)
+		}
+	}
+	if got := fmt.Sprintf("%n", GetStackTracer(testDefinition.New("x")).StackTrace()[0]); got != "TestDefinitionSkip" {
+		t.Errorf("New: got stack trace from %s", got)
+	}
+}
+
+func TestDefine(t *testing.T) {
+	mustPanic := func(name string, fn func()) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: did not panic", name)
+			}
+		}()
+		fn()
+	}
+	mustPanic("empty code", func() { Define(Definition{}) })
+	mustPanic("duplicate code", func() { Define(Definition{Code: "TEST_OUT_OF_STOCK"}) })
+
+	if def, ok := LookupDefinition("TEST_OUT_OF_STOCK"); !ok || def != testDefinition {
+		t.Errorf("LookupDefinition: got %v, %v", def, ok)
+	}
+	found := false
+	for _, def := range Definitions() {
+		found = found || def == testDefinition
+	}
+	if !found {
+		t.Errorf("Definitions: definition is missing")
+	}
+}
+
+func TestKinds(t *testing.T) {
+	kinds := Kinds()
+	if len(kinds) == 0 || kinds[0] != "Timeout" {
+		t.Errorf("Kinds: got %v", kinds)
+	}
+}
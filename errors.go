@@ -51,6 +51,8 @@
 // causer interface is not exported by this package, but is considered a part
 // of stable public API.
 // errors.Unwrap is also available: this will retrieve the next error in the chain.
+// The wrappers of this package also implement the Unwrap method, thus the
+// chain can be inspected by errors.Is and errors.As of the standard library.
 //
 // Formatted printing of errors
 //
@@ -61,7 +63,8 @@
 //           printed recursively
 //     %v    see %s
 //     %+v   extended format. Each Frame of the error's StackTrace will
-//           be printed in detail.
+//           be printed in detail, as well as the format specifier and
+//           arguments of messages and the attached context fields.
 //
 // Retrieving the stack trace of an error or wrapper
 //
@@ -89,22 +92,22 @@ package errors
 import (
 	"fmt"
 	"io"
-	"sort"
 )
 
 // New returns an error with the supplied message.
 // New also records the stack trace at the point it was called.
 //
 // If len(fields) > 0, the additional context information will be attached
-// to the error by calling WithFields.
-func New(message string, fields ...map[string]interface{}) error {
+// to the error by calling With, fields can be maps, Field values, or
+// key value pairs.
+func New(message string, fields ...interface{}) error {
 	var err error
 	err = &fundamental{
-		msg:   message,
+		msg:   plainMessage(message),
 		stack: callers(),
 	}
 	if len(fields) > 0 {
-		err = WithFields(err, fields...)
+		err = With(err, fields...)
 	}
 	return err
 }
@@ -112,13 +115,71 @@ func New(message string, fields ...map[string]interface{}) error {
 // Errorf formats according to a format specifier and returns the string
 // as a value that satisfies error.
 // Errorf also records the stack trace at the point it was called.
+//
+// The format specifier and arguments are kept by the error, see
+// MessageTemplater, the message is rendered immediately, thus changing
+// the arguments later does not change the message.
 func Errorf(format string, args ...interface{}) error {
 	return &fundamental{
-		msg:   fmt.Sprintf(format, args...),
+		msg:   formatMessage(format, args),
 		stack: callers(),
 	}
 }
 
+// MessageTemplater is implemented by errors of this package which carry
+// a message, it gives access to the format specifier and arguments the
+// message was created with, separately from the rendered text.
+//
+// For errors created with a plain message, e.g. New or Wrap, the template
+// is the message itself and there is no argument.
+type MessageTemplater interface {
+	MessageTemplate() string
+	MessageArgs() []interface{}
+}
+
+// message is an error message which is either a plain string, or
+// a format specifier and arguments. The text is rendered when the message
+// is created, the format specifier and arguments are kept for
+// MessageTemplater and for redaction.
+type message struct {
+	text      string
+	format    string
+	args      []interface{}
+	formatted bool
+}
+
+func plainMessage(text string) *message {
+	return &message{text: text}
+}
+
+func formatMessage(format string, args []interface{}) *message {
+	return &message{
+		text:      fmt.Sprintf(format, args...),
+		format:    format,
+		args:      args,
+		formatted: true,
+	}
+}
+
+func (m *message) String() string {
+	return m.text
+}
+
+func (m *message) template() string {
+	if m.formatted {
+		return m.format
+	}
+	return m.text
+}
+
+// formatTemplate writes the format specifier and arguments to s,
+// if the message has any argument.
+func (m *message) formatTemplate(s fmt.State) {
+	if len(m.args) > 0 {
+		fmt.Fprintf(s, "\ntemplate: %q args: %v", m.format, m.args)
+	}
+}
+
 // StackTraceAware is an optimization to avoid repetitive traversals of an error chain.
 // HasStack checks for this marker first.
 // Annotate/Wrap and Annotatef/Wrapf will produce this marker.
@@ -136,25 +197,28 @@ func HasStack(err error) bool {
 
 // fundamental is an error that has a message and a stack, but no caller.
 type fundamental struct {
-	msg string
+	msg *message
 	*stack
 }
 
-func (f *fundamental) Error() string { return f.msg }
+func (f *fundamental) Error() string              { return f.msg.String() }
+func (f *fundamental) MessageTemplate() string    { return f.msg.template() }
+func (f *fundamental) MessageArgs() []interface{} { return f.msg.args }
 
 func (f *fundamental) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
-			io.WriteString(s, f.msg)
+			io.WriteString(s, f.msg.String())
+			f.msg.formatTemplate(s)
 			f.stack.Format(s, verb)
 			return
 		}
 		fallthrough
 	case 's':
-		io.WriteString(s, f.msg)
+		io.WriteString(s, f.msg.String())
 	case 'q':
-		fmt.Fprintf(s, "%q", f.msg)
+		fmt.Fprintf(s, "%q", f.msg.String())
 	}
 }
 
@@ -162,13 +226,14 @@ func (f *fundamental) Format(s fmt.State, verb rune) {
 // If err is nil, WithStack returns nil.
 //
 // If len(fields) > 0, the additional context information will be attached
-// to the error by calling WithFields.
+// to the error by calling With, fields can be maps, Field values, or
+// key value pairs.
 //
 // For most use cases this is deprecated and AddStack should be used
 // (which will ensure just one stack trace).
 // However, one may want to use this in some situations, for example to
 // create a 2nd trace across a goroutine.
-func WithStack(err error, fields ...map[string]interface{}) error {
+func WithStack(err error, fields ...interface{}) error {
 	if err == nil {
 		return nil
 	}
@@ -178,7 +243,7 @@ func WithStack(err error, fields ...map[string]interface{}) error {
 		stack: callers(),
 	}
 	if len(fields) > 0 {
-		err = WithFields(err, fields...)
+		err = With(err, fields...)
 	}
 	return err
 }
@@ -186,7 +251,7 @@ func WithStack(err error, fields ...map[string]interface{}) error {
 // AddStack is similar to WithStack.
 // However, it will first check with HasStack to see if a stack trace already
 // exists in the causer chain before creating another one.
-func AddStack(err error, fields ...map[string]interface{}) error {
+func AddStack(err error, fields ...interface{}) error {
 	if err == nil {
 		return nil
 	}
@@ -198,7 +263,7 @@ func AddStack(err error, fields ...map[string]interface{}) error {
 		}
 	}
 	if len(fields) > 0 {
-		err = WithFields(err, fields...)
+		err = With(err, fields...)
 	}
 	return err
 }
@@ -209,6 +274,7 @@ type withStack struct {
 }
 
 func (w *withStack) Cause() error   { return w.error }
+func (w *withStack) Unwrap() error  { return w.error }
 func (w *withStack) HasStack() bool { return true }
 
 func (w *withStack) Format(s fmt.State, verb rune) {
@@ -232,28 +298,36 @@ func (w *withStack) Format(s fmt.State, verb rune) {
 // If err is nil, Wrap returns nil.
 //
 // If len(fields) > 0, the additional context information will be attached
-// to the error by calling WithFields.
-func Wrap(err error, message string, fields ...map[string]interface{}) error {
+// to the error by calling With, fields can be maps, Field values, or
+// key value pairs.
+func Wrap(err error, message string, fields ...interface{}) error {
 	if err == nil {
 		return nil
 	}
+	err = wrap(err, message, 4)
+	if len(fields) > 0 {
+		err = With(err, fields...)
+	}
+	return err
+}
+
+// wrap implements Wrap, the stack trace is recorded by callersSkip(skip)
+// if err does not have one.
+func wrap(err error, message string, skip int) error {
 	hasStack := HasStack(err)
 	if message != "" {
 		err = &withMessage{
 			cause:         err,
-			msg:           message,
+			msg:           plainMessage(message),
 			causeHasStack: hasStack,
 		}
 	}
 	if !hasStack {
 		err = &withStack{
 			error: err,
-			stack: callers(),
+			stack: callersSkip(skip),
 		}
 	}
-	if len(fields) > 0 {
-		err = WithFields(err, fields...)
-	}
 	return err
 }
 
@@ -267,7 +341,7 @@ func Wrapf(err error, format string, args ...interface{}) error {
 	hasStack := HasStack(err)
 	err = &withMessage{
 		cause:         err,
-		msg:           fmt.Sprintf(format, args...),
+		msg:           formatMessage(format, args),
 		causeHasStack: HasStack(err),
 	}
 	if hasStack {
@@ -283,18 +357,19 @@ func Wrapf(err error, format string, args ...interface{}) error {
 // If err is nil, WithMessage returns nil.
 //
 // If len(fields) > 0, the additional context information will be attached
-// to the error by calling WithFields.
-func WithMessage(err error, message string, fields ...map[string]interface{}) error {
+// to the error by calling With, fields can be maps, Field values, or
+// key value pairs.
+func WithMessage(err error, message string, fields ...interface{}) error {
 	if err == nil {
 		return nil
 	}
 	err = &withMessage{
 		cause:         err,
-		msg:           message,
+		msg:           plainMessage(message),
 		causeHasStack: HasStack(err),
 	}
 	if len(fields) > 0 {
-		err = WithFields(err, fields...)
+		err = With(err, fields...)
 	}
 	return err
 }
@@ -306,27 +381,32 @@ func WithMessagef(err error, format string, args ...interface{}) error {
 		return nil
 	}
 	return &withMessage{
-		cause: err,
-		msg:   fmt.Sprintf(format, args...),
+		cause:         err,
+		msg:           formatMessage(format, args),
+		causeHasStack: HasStack(err),
 	}
 }
 
 type withMessage struct {
 	cause         error
-	msg           string
+	msg           *message
 	causeHasStack bool
 }
 
-func (w *withMessage) Error() string  { return w.msg + ": " + w.cause.Error() }
-func (w *withMessage) Cause() error   { return w.cause }
-func (w *withMessage) HasStack() bool { return w.causeHasStack }
+func (w *withMessage) Error() string              { return w.msg.String() + ": " + w.cause.Error() }
+func (w *withMessage) Cause() error               { return w.cause }
+func (w *withMessage) Unwrap() error              { return w.cause }
+func (w *withMessage) HasStack() bool             { return w.causeHasStack }
+func (w *withMessage) MessageTemplate() string    { return w.msg.template() }
+func (w *withMessage) MessageArgs() []interface{} { return w.msg.args }
 
 func (w *withMessage) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
 			fmt.Fprintf(s, "%+v\n", w.Cause())
-			io.WriteString(s, w.msg)
+			io.WriteString(s, w.msg.String())
+			w.msg.formatTemplate(s)
 			return
 		}
 		fallthrough
@@ -335,103 +415,33 @@ func (w *withMessage) Format(s fmt.State, verb rune) {
 	}
 }
 
-type F map[string]interface{}
-
-func (f F) AsList() []interface{} {
-	if len(f) == 0 {
-		return nil
-	}
-	ll := make([]interface{}, 0, len(f)*2)
-	for k, v := range f {
-		ll = append(ll, k, v)
-	}
-	return ll
-}
-
-type withFields struct {
-	error
-	fields        F
+// annotation is embedded by the errors which annotate their cause with
+// a value without changing its message, e.g. a kind, a severity or
+// a retry hint. Such errors are skipped by the message and layer based
+// outputs, e.g. Redacted and Fingerprint, see annotator.
+type annotation struct {
+	cause         error
 	causeHasStack bool
 }
 
-func (w *withFields) Cause() error   { return w.error }
-func (w *withFields) HasStack() bool { return w.causeHasStack }
-
-func (w *withFields) Format(s fmt.State, verb rune) {
-	switch verb {
-	case 'v':
-		if s.Flag('+') {
-			fmt.Fprintf(s, "%+v", w.Cause())
-			if len(w.fields) > 0 {
-				keys := make([]string, 0, len(w.fields))
-				for k := range w.fields {
-					keys = append(keys, k)
-				}
-				sort.Strings(keys)
-
-				fmt.Fprint(s, "\ncontext:")
-				for _, k := range keys {
-					w.appendKeyValue(s, k, w.fields[k])
-				}
-			}
-			return
-		}
-		fallthrough
-	case 's', 'q':
-		io.WriteString(s, w.Error())
-	}
+func newAnnotation(err error) annotation {
+	return annotation{cause: err, causeHasStack: HasStack(err)}
 }
 
-func (w *withFields) appendKeyValue(s fmt.State, key string, value interface{}) {
-	fmt.Fprintf(s, " %s=", key)
-	stringVal, ok := value.(string)
-	if !ok {
-		stringVal = fmt.Sprint(value)
-	}
-	if !w.needsQuoting(stringVal) {
-		fmt.Fprint(s, stringVal)
-	} else {
-		fmt.Fprintf(s, "%q", stringVal)
-	}
-}
+func (a *annotation) Error() string   { return a.cause.Error() }
+func (a *annotation) Cause() error    { return a.cause }
+func (a *annotation) Unwrap() error   { return a.cause }
+func (a *annotation) HasStack() bool  { return a.causeHasStack }
+func (a *annotation) annotationOnly() {}
 
-func (w *withFields) needsQuoting(text string) bool {
-	for _, ch := range text {
-		if !((ch >= 'a' && ch <= 'z') ||
-			(ch >= 'A' && ch <= 'Z') ||
-			(ch >= '0' && ch <= '9') ||
-			ch == '-' || ch == '.' || ch == '_' || ch == '/' || ch == '@' || ch == '^' || ch == '+') {
-			return true
-		}
-	}
-	return false
+func (a *annotation) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, fmt.FormatString(s, verb), a.cause)
 }
 
-// WithFields attaches given additional context information to err.
-// If err is nil, WithFields returns nil.
-// If len(fields) == 0, WithFields returns the original err.
-func WithFields(err error, fields ...map[string]interface{}) error {
-	if err == nil {
-		return nil
-	}
-	if len(fields) == 0 {
-		return err
-	}
-	oldFields := Fields(err)
-	ff := make(F, len(fields[0])*len(fields)+len(oldFields))
-	for k, v := range oldFields {
-		ff[k] = v
-	}
-	for _, f := range fields {
-		for k, v := range f {
-			ff[k] = v
-		}
-	}
-	return &withFields{
-		error:         err,
-		fields:        ff,
-		causeHasStack: HasStack(err),
-	}
+// annotator is implemented by the errors which embed annotation.
+type annotator interface {
+	error
+	annotationOnly()
 }
 
 // Cause returns the underlying cause of the error, if possible.
@@ -490,15 +500,3 @@ func Find(origErr error, test func(error) bool) error {
 	})
 	return foundErr
 }
-
-// Fields returns attached fields of the given error if available, else nil.
-func Fields(err error) F {
-	fieldsErr := Find(err, func(err error) bool {
-		_, ok := err.(*withFields)
-		return ok
-	})
-	if fieldsErr == nil {
-		return nil
-	}
-	return fieldsErr.(*withFields).fields
-}
@@ -383,3 +383,27 @@ func TestWalkDeep(t *testing.T) {
 		t.Errorf("found not exists")
 	}
 }
+
+func TestStdUnwrap(t *testing.T) {
+	wrappers := []func(error) error{
+		func(err error) error { return WithStack(err) },
+		func(err error) error { return Wrap(err, "read") },
+		func(err error) error { return WithMessage(err, "read") },
+		func(err error) error { return With(err, "k", 1) },
+		func(err error) error { return WithKind(err, "Timeout") },
+		func(err error) error { return Retryable(err, 0) },
+		func(err error) error { return WithSeverity(err, Critical) },
+		func(err error) error { return WithPublicMessage(err, "oops") },
+		func(err error) error { return WithViolations(err, FieldViolation{Field: "f"}) },
+		func(err error) error { return RedactedError(err) },
+	}
+	for i, wrap := range wrappers {
+		err := wrap(io.EOF)
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("%d: errors.Is: got false for %T", i, err)
+		}
+		if errors.Unwrap(err) != Unwrap(err) {
+			t.Errorf("%d: errors.Unwrap: got %T, want %T", i, errors.Unwrap(err), Unwrap(err))
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package errors
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+type fieldKind uint8
+
+const (
+	anyField fieldKind = iota
+	stringField
+	intField
+	int64Field
+	uint64Field
+	float64Field
+	boolField
+	durationField
+	lazyField
+)
+
+// badKey is the key of a field when a key value pair is malformed.
+const badKey = "!BADKEY"
+
+// Field is a key value pair which can be attached to an error.
+// Fields created by the typed constructors, e.g. String and Int,
+// store the value without boxing it into an interface.
+type Field struct {
+	Key string
+
+	kind fieldKind
+	num  uint64
+	str  string
+	any  interface{}
+}
+
+// String returns a Field for a string value.
+func String(key, value string) Field {
+	return Field{Key: key, kind: stringField, str: value}
+}
+
+// Int returns a Field for an int value.
+func Int(key string, value int) Field {
+	return Field{Key: key, kind: intField, num: uint64(value)}
+}
+
+// Int64 returns a Field for an int64 value.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, kind: int64Field, num: uint64(value)}
+}
+
+// Uint64 returns a Field for an uint64 value.
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, kind: uint64Field, num: value}
+}
+
+// Float64 returns a Field for a float64 value.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, kind: float64Field, num: math.Float64bits(value)}
+}
+
+// Bool returns a Field for a bool value.
+func Bool(key string, value bool) Field {
+	var num uint64
+	if value {
+		num = 1
+	}
+	return Field{Key: key, kind: boolField, num: num}
+}
+
+// Duration returns a Field for a time.Duration value.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, kind: durationField, num: uint64(value)}
+}
+
+// Any returns a Field for an arbitrary value.
+// If value is a func() interface{}, the field is lazy, see Lazy.
+func Any(key string, value interface{}) Field {
+	if fn, ok := value.(func() interface{}); ok {
+		return Lazy(key, fn)
+	}
+	return Field{Key: key, kind: anyField, any: value}
+}
+
+// Lazy returns a Field whose value is computed by calling fn when it is
+// first used, e.g. when the error is formatted by "%+v", or the fields
+// are retrieved by Fields. It is useful for context which is expensive
+// to compute and only needed if the error is actually reported.
+//
+// A func() interface{} value given to WithFields, With, or the other
+// functions which accept fields, is also evaluated lazily.
+// fn is called at most once, if it panics, the value is a string
+// describing the panic.
+func Lazy(key string, fn func() interface{}) Field {
+	return Field{Key: key, kind: lazyField, any: &lazyValue{fn: fn}}
+}
+
+type lazyValue struct {
+	once  sync.Once
+	fn    func() interface{}
+	value interface{}
+}
+
+func (x *lazyValue) get() interface{} {
+	x.once.Do(func() {
+		defer func() {
+			if p := recover(); p != nil {
+				x.value = fmt.Sprintf("!PANIC(%v)", p)
+			}
+		}()
+		x.value = x.fn()
+	})
+	return x.value
+}
+
+// Value returns the value of the field, with the type which was given
+// to the constructor.
+func (f Field) Value() interface{} {
+	switch f.kind {
+	case stringField:
+		return f.str
+	case intField:
+		return int(f.num)
+	case int64Field:
+		return int64(f.num)
+	case uint64Field:
+		return f.num
+	case float64Field:
+		return math.Float64frombits(f.num)
+	case boolField:
+		return f.num == 1
+	case durationField:
+		return time.Duration(f.num)
+	case lazyField:
+		return f.any.(*lazyValue).get()
+	}
+	return f.any
+}
+
+// With attaches fields to err, like WithFields, but without allocating
+// a map. Each argument is one of
+//
+//   - a string key followed by its value, e.g. With(err, "table", t)
+//   - a Field, e.g. With(err, errors.Int("id", id))
+//   - a []Field
+//   - a map with string keys, e.g. F, map[string]interface{} or logrus.Fields,
+//     the fields of a map are attached in key order
+//
+// A key without value, or an argument of any other type is attached with
+// the key "!BADKEY".
+//
+// If err is nil, With returns nil.
+// If len(args) == 0, With returns the original err.
+func With(err error, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	if len(args) == 0 {
+		return err
+	}
+	return &withFields{
+		error:         err,
+		fields:        argsToFields(args),
+		causeHasStack: HasStack(err),
+	}
+}
+
+// WithFieldList attaches fields to err, like With, but avoids allocating
+// an interface value for each field.
+//
+// If err is nil, WithFieldList returns nil.
+// If len(fields) == 0, WithFieldList returns the original err.
+func WithFieldList(err error, fields ...Field) error {
+	if err == nil {
+		return nil
+	}
+	if len(fields) == 0 {
+		return err
+	}
+	list := make([]Field, 0, len(fields))
+	for _, f := range fields {
+		list = appendField(list, f)
+	}
+	return &withFields{
+		error:         err,
+		fields:        list,
+		causeHasStack: HasStack(err),
+	}
+}
+
+func argsToFields(args []interface{}) []Field {
+	fields := make([]Field, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch x := args[i].(type) {
+		case string:
+			if i+1 < len(args) {
+				fields = appendField(fields, Any(x, args[i+1]))
+				i++
+			} else {
+				fields = appendField(fields, Any(badKey, x))
+			}
+		case Field:
+			fields = appendField(fields, x)
+		case []Field:
+			for _, f := range x {
+				fields = appendField(fields, f)
+			}
+		case F:
+			fields = appendMap(fields, x)
+		case map[string]interface{}:
+			fields = appendMap(fields, x)
+		default:
+			fields = appendReflectMap(fields, x)
+		}
+	}
+	return fields
+}
+
+func appendMap(fields []Field, m map[string]interface{}) []Field {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = appendField(fields, Any(k, m[k]))
+	}
+	return fields
+}
+
+// appendReflectMap appends the entries of x if it is a map with string
+// keys, else x is appended with the key "!BADKEY".
+func appendReflectMap(fields []Field, x interface{}) []Field {
+	v := reflect.ValueOf(x)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return appendField(fields, Any(badKey, x))
+	}
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, k := range keys {
+		fields = appendField(fields, Any(k.String(), v.MapIndex(k).Interface()))
+	}
+	return fields
+}
+
+// appendField appends f to fields, replacing the field with the same key
+// if there is one.
+func appendField(fields []Field, f Field) []Field {
+	for i := range fields {
+		if fields[i].Key == f.Key {
+			fields[i] = f
+			return fields
+		}
+	}
+	return append(fields, f)
+}
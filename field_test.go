@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type namedFields map[string]interface{}
+
+func TestFieldValue(t *testing.T) {
+	is := assert.New(t)
+	tests := []struct {
+		field Field
+		want  interface{}
+	}{
+		{String("k", "v"), "v"},
+		{Int("k", -1), -1},
+		{Int64("k", -2), int64(-2)},
+		{Uint64("k", 3), uint64(3)},
+		{Float64("k", 1.5), 1.5},
+		{Bool("k", true), true},
+		{Bool("k", false), false},
+		{Duration("k", time.Second), time.Second},
+		{Any("k", []int{1}), []int{1}},
+	}
+	for _, tt := range tests {
+		is.Equal(tt.want, tt.field.Value())
+	}
+}
+
+func TestWith(t *testing.T) {
+	is := assert.New(t)
+	is.Nil(With(nil, "k", "v"))
+	is.Equal(io.EOF, With(io.EOF))
+	is.Nil(WithFieldList(nil, Int("k", 1)))
+	is.Equal(io.EOF, WithFieldList(io.EOF))
+
+	err := With(io.EOF,
+		"table", "users",
+		Int("id", 1),
+		[]Field{Bool("ok", false)},
+		F{"b": 2, "a": 1},
+		namedFields{"c": 3},
+		"id", 2,
+		42,
+		"dangling",
+	)
+	is.Equal([]FieldLayer{{Depth: 0, Fields: []KV{
+		{"table", "users"},
+		{"id", 2},
+		{"ok", false},
+		{"a", 1},
+		{"b", 2},
+		{"c", 3},
+		{badKey, "dangling"},
+	}}}, FieldLayers(err))
+
+	err = WithFieldList(io.EOF, String("s", "x y"), Int("id", 1), Duration("d", time.Millisecond))
+	is.Equal("EOF\ncontext: s=\"x y\" id=1 d=1ms", fmt.Sprintf("%+v", err))
+}
+
+func TestFieldsArguments(t *testing.T) {
+	is := assert.New(t)
+	want := F{"k": "v", "id": 1}
+	for _, err := range []error{
+		New("new", "k", "v", Int("id", 1)),
+		Wrap(io.EOF, "wrap", F{"k": "v"}, Int("id", 1)),
+		WithStack(io.EOF, map[string]interface{}{"k": "v", "id": 1}),
+		AddStack(io.EOF, namedFields{"k": "v"}, "id", 1),
+		WithMessage(io.EOF, "message", []Field{String("k", "v"), Int("id", 1)}),
+	} {
+		is.Equal(want, Fields(err), "%v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package errors
+
+import "time"
+
+// FieldKey is a typed key of a field, it ensures that the values attached
+// and retrieved by the key have the same type. A FieldKey is usually
+// declared as a package level variable, e.g.
+//
+//     var UserID = errors.NewFieldKey[int64]("user_id")
+//
+//     err = errors.WithField(err, UserID, 42)
+//     id, ok := errors.FieldValue(err, UserID)
+//
+// Fields attached by a FieldKey are ordinary key value pairs, they are
+// returned by Fields and printed by "%+v" like the fields attached by
+// WithFields or With.
+type FieldKey[T any] struct {
+	name string
+}
+
+// NewFieldKey returns a FieldKey with the given name.
+func NewFieldKey[T any](name string) FieldKey[T] {
+	return FieldKey[T]{name: name}
+}
+
+// Name returns the name of the key.
+func (k FieldKey[T]) Name() string { return k.name }
+
+// Field returns a Field with the key name and value v, which can be
+// given to With, WithFieldList, or the other functions accepting fields.
+func (k FieldKey[T]) Field(v T) Field {
+	switch x := any(v).(type) {
+	case string:
+		return String(k.name, x)
+	case int:
+		return Int(k.name, x)
+	case int64:
+		return Int64(k.name, x)
+	case uint64:
+		return Uint64(k.name, x)
+	case float64:
+		return Float64(k.name, x)
+	case bool:
+		return Bool(k.name, x)
+	case time.Duration:
+		return Duration(k.name, x)
+	}
+	return Any(k.name, v)
+}
+
+// WithField attaches the value v with key to err.
+// If err is nil, WithField returns nil.
+func WithField[T any](err error, key FieldKey[T], v T) error {
+	return WithFieldList(err, key.Field(v))
+}
+
+// FieldValue returns the value attached to err with key, the returned bool
+// is false if the key is not found, or the value attached with the same
+// name has a different type, e.g. it was attached by WithFields with an
+// int value for a FieldKey[int64].
+//
+// Like Fields, the value attached by the outermost layer takes precedence.
+// Note that the global MaskPolicy is not applied.
+func FieldValue[T any](err error, key FieldKey[T]) (T, bool) {
+	v, ok := lookupField(err, key.name)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	x, ok := v.(T)
+	return x, ok
+}
@@ -0,0 +1,56 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	Name string
+}
+
+func TestFieldKey(t *testing.T) {
+	is := assert.New(t)
+
+	userID := NewFieldKey[int64]("user_id")
+	timeout := NewFieldKey[time.Duration]("timeout")
+	user := NewFieldKey[*testUser]("user")
+	is.Equal("user_id", userID.Name())
+
+	u := &testUser{Name: "alice"}
+	err := WithField(io.EOF, userID, 42)
+	err = WithMessage(err, "query", "table", "users")
+	err = WithField(err, timeout, time.Second)
+	err = WithField(err, user, u)
+
+	id, ok := FieldValue(err, userID)
+	is.True(ok)
+	is.Equal(int64(42), id)
+	d, ok := FieldValue(err, timeout)
+	is.True(ok)
+	is.Equal(time.Second, d)
+	got, ok := FieldValue(err, user)
+	is.True(ok)
+	is.Same(u, got)
+
+	is.Equal(F{"user_id": int64(42), "table": "users", "timeout": time.Second, "user": u}, Fields(err))
+	is.Contains(fmt.Sprintf("%+v", err), "context: user_id=42")
+
+	// The outermost layer wins.
+	id, _ = FieldValue(WithField(err, userID, 7), userID)
+	is.Equal(int64(7), id)
+
+	// Missing key, and mismatched type attached by a map.
+	_, ok = FieldValue(io.EOF, userID)
+	is.False(ok)
+	_, ok = FieldValue(WithFields(io.EOF, F{"user_id": 42}), userID)
+	is.False(ok)
+	_, ok = FieldValue(WithFields(io.EOF, F{"user_id": int64(42)}), userID)
+	is.True(ok)
+
+	is.Nil(WithField(nil, userID, 42))
+}
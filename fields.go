@@ -0,0 +1,219 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+type F map[string]interface{}
+
+func (f F) AsList() []interface{} {
+	if len(f) == 0 {
+		return nil
+	}
+	ll := make([]interface{}, 0, len(f)*2)
+	for k, v := range f {
+		ll = append(ll, k, v)
+	}
+	return ll
+}
+
+// KV is a key value pair attached to an error.
+type KV struct {
+	Key   string
+	Value interface{}
+}
+
+// FieldLayer holds the fields attached to an error chain by one call
+// of WithFields, With, or the other functions which accept fields.
+type FieldLayer struct {
+	// Depth is the position of the layer in the error chain, the
+	// outermost error has depth 0.
+	Depth int
+
+	// Fields are the attached fields in the order they were given.
+	// Fields given by a map are ordered by key.
+	Fields []KV
+}
+
+// withFields holds the fields attached by a single call of WithFields,
+// fields attached by earlier calls are kept by the wrapped errors.
+type withFields struct {
+	error
+	fields        []Field
+	causeHasStack bool
+}
+
+func (w *withFields) Cause() error   { return w.error }
+func (w *withFields) Unwrap() error  { return w.error }
+func (w *withFields) HasStack() bool { return w.causeHasStack }
+
+func (w *withFields) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", w.Cause())
+			if len(w.fields) > 0 {
+				policy := GetMaskPolicy()
+				fmt.Fprint(s, "\ncontext:")
+				for _, f := range w.fields {
+					if v, ok := policy.apply(f.Key, f.Value()); ok {
+						w.appendKeyValue(s, f.Key, v)
+					}
+				}
+			}
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		io.WriteString(s, w.Error())
+	}
+}
+
+func (w *withFields) appendKeyValue(s fmt.State, key string, value interface{}) {
+	fmt.Fprintf(s, " %s=", key)
+	stringVal := FormatValue(value, MaxFieldValueLen)
+	if !w.needsQuoting(stringVal) {
+		fmt.Fprint(s, stringVal)
+	} else {
+		fmt.Fprintf(s, "%q", stringVal)
+	}
+}
+
+func (w *withFields) needsQuoting(text string) bool {
+	for _, ch := range text {
+		if !((ch >= 'a' && ch <= 'z') ||
+			(ch >= 'A' && ch <= 'Z') ||
+			(ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '.' || ch == '_' || ch == '/' || ch == '@' || ch == '^' || ch == '+') {
+			return true
+		}
+	}
+	return false
+}
+
+// WithFields attaches given additional context information to err.
+// If err is nil, WithFields returns nil.
+// If len(fields) == 0, WithFields returns the original err.
+//
+// Only the given fields are stored by the returned error, fields attached
+// to err earlier are merged when they are retrieved by Fields.
+func WithFields(err error, fields ...map[string]interface{}) error {
+	if err == nil {
+		return nil
+	}
+	if len(fields) == 0 {
+		return err
+	}
+	size := 0
+	for _, f := range fields {
+		size += len(f)
+	}
+	list := make([]Field, 0, size)
+	for _, f := range fields {
+		list = appendMap(list, f)
+	}
+	return &withFields{
+		error:         err,
+		fields:        list,
+		causeHasStack: HasStack(err),
+	}
+}
+
+// Fields returns attached fields of the given error if available, else nil.
+// The global MaskPolicy is applied to the returned fields, see SetMaskPolicy.
+//
+// The fields attached by all layers of the error chain are merged, if
+// several layers attach the same key, the value attached by the outermost
+// layer, i.e. the latest call, takes precedence.
+// Use FieldLayers to find out which layer attached which value.
+func Fields(err error) F {
+	fields := rawFields(err)
+	if policy := GetMaskPolicy(); len(policy) > 0 {
+		return policy.Apply(fields)
+	}
+	return fields
+}
+
+func rawFields(err error) F {
+	fieldsErr, _ := findFields(err)
+	if fieldsErr == nil {
+		return nil
+	}
+	var layers []*withFields
+	size := 0
+	for e := fieldsErr; e != nil; e = Unwrap(e) {
+		if w, ok := e.(*withFields); ok {
+			layers = append(layers, w)
+			size += len(w.fields)
+		}
+	}
+	fields := make(F, size)
+	for i := len(layers) - 1; i >= 0; i-- {
+		for _, f := range layers[i].fields {
+			fields[f.Key] = f.Value()
+		}
+	}
+	return fields
+}
+
+// lookupField returns the value attached to the error chain of err with
+// key, the outermost layer takes precedence.
+func lookupField(err error, key string) (interface{}, bool) {
+	fieldsErr, _ := findFields(err)
+	for e := fieldsErr; e != nil; e = Unwrap(e) {
+		if w, ok := e.(*withFields); ok {
+			for _, f := range w.fields {
+				if f.Key == key {
+					return f.Value(), true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// findFields returns the outermost error in the chain of err which has
+// attached fields, and its depth in the chain. If there is none, the
+// first one in the members of ErrorGroup is returned, as found by Find,
+// the depth is then counted from the member.
+func findFields(err error) (error, int) {
+	fieldsErr := Find(err, func(err error) bool {
+		_, ok := err.(*withFields)
+		return ok
+	})
+	if fieldsErr == nil {
+		return nil, 0
+	}
+	depth := 0
+	for e := err; e != nil; e = Unwrap(e) {
+		if e == fieldsErr {
+			return fieldsErr, depth
+		}
+		depth++
+	}
+	return fieldsErr, 0
+}
+
+// FieldLayers returns the fields attached to the error chain by each layer,
+// from the outermost layer to the innermost one.
+//
+// If there is no field attached to the chain of err itself, the fields of
+// the first member of an ErrorGroup which has fields are returned, as
+// found by Find.
+// Note that the global MaskPolicy is not applied.
+func FieldLayers(err error) []FieldLayer {
+	fieldsErr, depth := findFields(err)
+	var layers []FieldLayer
+	for e := fieldsErr; e != nil; e = Unwrap(e) {
+		if w, ok := e.(*withFields); ok {
+			kvs := make([]KV, len(w.fields))
+			for i, f := range w.fields {
+				kvs[i] = KV{Key: f.Key, Value: f.Value()}
+			}
+			layers = append(layers, FieldLayer{Depth: depth, Fields: kvs})
+		}
+		depth++
+	}
+	return layers
+}
@@ -0,0 +1,36 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldLayers(t *testing.T) {
+	is := assert.New(t)
+	is.Nil(FieldLayers(io.EOF))
+	is.Nil(Fields(io.EOF))
+
+	err := WithFields(io.EOF, F{"b": 1, "a": 1})
+	err = WithMessage(err, "read")
+	err = WithFields(err, F{"c": 2}, F{"a": 2})
+
+	is.Equal(F{"a": 2, "b": 1, "c": 2}, Fields(err))
+	is.Equal([]FieldLayer{
+		{Depth: 0, Fields: []KV{{"c", 2}, {"a", 2}}},
+		{Depth: 2, Fields: []KV{{"a", 1}, {"b", 1}}},
+	}, FieldLayers(err))
+	is.Equal("EOF\ncontext: a=1 b=1\nread\ncontext: c=2 a=2", fmt.Sprintf("%+v", err))
+
+	// Modifying the returned values does not affect the error.
+	Fields(err)["a"] = 3
+	FieldLayers(err)[0].Fields[1].Value = 3
+	is.Equal(2, Fields(err)["a"])
+
+	// Fields of an ErrorGroup member.
+	merr := Append(nil, io.EOF, WithFields(WithMessage(io.EOF, "x"), F{"m": 1}))
+	is.Equal(F{"m": 1}, Fields(merr))
+	is.Equal([]FieldLayer{{Depth: 0, Fields: []KV{{"m", 1}}}}, FieldLayers(merr))
+}
@@ -0,0 +1,163 @@
+package errors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// FingerprintFrames is the number of in-module stack frames used
+// by Fingerprint.
+var FingerprintFrames = 3
+
+// Fingerprint returns a stable hash of err which can be used to group
+// occurrences of the same failure.
+//
+// The fingerprint is built from the errors in the chain which carry
+// a message, each by its type and message template (see MessageTemplater)
+// or its message with variable parts such as numbers and quoted strings
+// replaced if there is no template, the kind reported by KindOf, and the
+// function names of the top FingerprintFrames stack frames which belong
+// to the main module, taken from the innermost stack trace of the chain,
+// i.e. where the error originated. File names and line numbers are not
+// used, thus the fingerprint does not change when unrelated code moves
+// around. Layers which only annotate an error, e.g. stack traces, fields,
+// severities and retry hints, are not used either, thus they can be added
+// to an error without moving it to another group.
+//
+// If err is nil, Fingerprint returns an empty string.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	h := sha256.New()
+	WalkDeep(err, func(err error) bool {
+		if _, ok := err.(ErrorGroup); ok {
+			io.WriteString(h, "group\n")
+		}
+		if msg, ok := messageTemplate(err); ok {
+			fmt.Fprintf(h, "type:%T\n", err)
+			io.WriteString(h, "msg:")
+			io.WriteString(h, msg)
+			io.WriteString(h, "\n")
+		}
+		return false
+	})
+	if kind := KindOf(err); kind != "" {
+		io.WriteString(h, "kind:"+kind+"\n")
+	}
+	if stacked := originStack(err); stacked != nil {
+		for _, fn := range moduleFunctions(stacked.StackTrace(), FingerprintFrames) {
+			io.WriteString(h, "func:"+fn+"\n")
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+var variableRE = regexp.MustCompile(
+	`"(?:[^"\\]|\\.)*"` + // double quoted strings
+		`|'(?:[^'\\]|\\.)*'` + // single quoted strings
+		`|\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b` + // UUIDs
+		`|\b0[xX][0-9a-fA-F]+\b` + // hexadecimal numbers
+		`|\d+(?:\.\d+)?`, // decimal numbers
+)
+
+// messageTemplate returns the message contributed by err itself to the
+// error chain. The format specifier is used if err was created with one,
+// otherwise variable parts of the message are replaced by placeholders.
+func messageTemplate(err error) (string, bool) {
+	switch err := err.(type) {
+	case MessageTemplater:
+		if len(err.MessageArgs()) > 0 {
+			return err.MessageTemplate(), true
+		}
+		return variableRE.ReplaceAllString(err.MessageTemplate(), "?"), true
+	case *withStack, *withFields, *withViolations, annotator, ErrorGroup:
+		return "", false
+	}
+	if Unwrap(err) != nil {
+		// The message of a foreign wrapper generally includes the
+		// message of its cause, which is handled separately.
+		return "", false
+	}
+	return variableRE.ReplaceAllString(err.Error(), "?"), true
+}
+
+// originStack returns the innermost StackTracer in the chain of err, or
+// the one found by GetStackTracer if there is none, e.g. for the members
+// of an ErrorGroup.
+func originStack(err error) StackTracer {
+	var origin StackTracer
+	for e := err; e != nil; e = Unwrap(e) {
+		if st, ok := e.(StackTracer); ok {
+			origin = st
+		}
+	}
+	if origin == nil {
+		return GetStackTracer(err)
+	}
+	return origin
+}
+
+var (
+	mainModuleOnce sync.Once
+	mainModule     string
+)
+
+// moduleFunctions returns the function names of the top n frames which
+// belong to the main module. If the main module is unknown, frames of
+// the standard library are skipped instead.
+func moduleFunctions(st StackTrace, n int) []string {
+	mainModuleOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			mainModule = info.Main.Path
+		}
+	})
+	out := make([]string, 0, n)
+	for _, f := range st {
+		if len(out) >= n {
+			break
+		}
+		fn := runtime.FuncForPC(uintptr(f) - 1)
+		if fn == nil {
+			continue
+		}
+		name := fn.Name()
+		if mainModule != "" {
+			if !strings.HasPrefix(name, mainModule) {
+				continue
+			}
+			rest := name[len(mainModule):]
+			if !strings.HasPrefix(rest, ".") && !strings.HasPrefix(rest, "/") {
+				continue
+			}
+		} else if isStdFunction(name) {
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// isStdFunction tells whether the function belongs to the standard
+// library, whose import paths have no dot in the first element.
+func isStdFunction(name string) bool {
+	pkgPath := name
+	slash := strings.LastIndexByte(pkgPath, '/')
+	if dot := strings.IndexByte(pkgPath[slash+1:], '.'); dot >= 0 {
+		pkgPath = pkgPath[:slash+1+dot]
+	}
+	if pkgPath == "main" {
+		return false
+	}
+	if i := strings.IndexByte(pkgPath, '/'); i >= 0 {
+		pkgPath = pkgPath[:i]
+	}
+	return !strings.Contains(pkgPath, ".")
+}
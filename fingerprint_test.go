@@ -0,0 +1,64 @@
+package errors
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fingerprintNotFound(id int) error {
+	return Annotatef(NotFoundf("user %d", id), "load profile of %q", "name")
+}
+
+func TestFingerprint(t *testing.T) {
+	is := assert.New(t)
+	is.Equal("", Fingerprint(nil))
+
+	fp1 := Fingerprint(fingerprintNotFound(1))
+	fp2 := Fingerprint(fingerprintNotFound(2))
+	is.Len(fp1, 32)
+	is.Equal(fp1, fp2)
+
+	// Same message but different kind or call site.
+	is.NotEqual(fp1, Fingerprint(Annotatef(Timeoutf("user %d", 1), "load profile of %q", "name")))
+	is.NotEqual(fp1, Fingerprint(Annotatef(NotFoundf("user %d", 1), "load profile of %q", "name")))
+
+	// Foreign errors are grouped by their messages.
+	is.Equal(Fingerprint(Wrap(io.EOF, "read")), Fingerprint(Wrap(io.EOF, "read")))
+	is.NotEqual(Fingerprint(WithMessage(io.EOF, "read")), Fingerprint(WithMessage(io.ErrUnexpectedEOF, "read")))
+
+	// Annotation-only layers do not change the fingerprint.
+	for _, annotate := range []func(error) error{
+		func(err error) error { return With(err, "k", 1) },
+		func(err error) error { return WithStack(err) },
+		func(err error) error { return Retryable(err, 0) },
+		func(err error) error { return WithSeverity(err, Critical) },
+		func(err error) error { return WithPublicMessage(err, "oops") },
+	} {
+		is.Equal(fp1, Fingerprint(annotate(fingerprintNotFound(1))))
+	}
+	is.NotEqual(fp1, Fingerprint(WithKind(fingerprintNotFound(1), "Timeout")))
+
+	merr := Append(nil, fingerprintNotFound(1), io.EOF)
+	is.NotEqual(Fingerprint(merr), Fingerprint(Append(nil, fingerprintNotFound(1))))
+}
+
+func TestMessageTemplate(t *testing.T) {
+	is := assert.New(t)
+	msg, ok := messageTemplate(New(`user 42 "alice" 0x1f 3.5 not found 123e4567-e89b-12d3-a456-426614174000`))
+	is.True(ok)
+	is.Equal(`user ? ? ? ? not found ?`, msg)
+
+	_, ok = messageTemplate(WithStack(io.EOF))
+	is.False(ok)
+}
+
+func TestIsStdFunction(t *testing.T) {
+	is := assert.New(t)
+	is.True(isStdFunction("runtime.goexit"))
+	is.True(isStdFunction("net/http.(*conn).serve"))
+	is.False(isStdFunction("main.main"))
+	is.False(isStdFunction("github.com/jxskiss/errors.New"))
+	is.False(isStdFunction("example.com/x/y.(*T).m.func1"))
+}
@@ -55,7 +55,7 @@ func TestFormatErrorf(t *testing.T) {
 	}, {
 		Errorf("%s", "error"),
 		"%+v",
-		"error\n" +
+		"error\n" + "template: \"%s\" args: \\[error\\]\n" +
 			"github.com/jxskiss/errors.TestFormatErrorf\n" +
 			"\t.+/github.com/jxskiss/errors/format_test.go:56",
 	}}
@@ -134,7 +134,7 @@ func TestFormatWrapf(t *testing.T) {
 		Annotatef(io.EOF, "error%d", 2),
 		"%+v",
 		"EOF\n" +
-			"error2\n" +
+			"error2\n" + "template: \"error%d\" args: \\[2\\]\n" +
 			"github.com/jxskiss/errors.TestFormatWrapf\n" +
 			"\t.+/github.com/jxskiss/errors/format_test.go:134",
 	}, {
@@ -215,7 +215,7 @@ func TestFormatWithStack(t *testing.T) {
 	}, {
 		WithStack(Errorf("error%d", 1)),
 		"%+v",
-		[]string{"error1",
+		[]string{"error1", `template: "error%d" args: [1]`,
 			"github.com/jxskiss/errors.TestFormatWithStack\n" +
 				"\t.+/github.com/jxskiss/errors/format_test.go:216",
 			"github.com/jxskiss/errors.TestFormatWithStack\n" +
@@ -277,7 +277,7 @@ func TestFormatWithMessage(t *testing.T) {
 	}, {
 		WithMessage(Errorf("error%d", 1), "error2"),
 		"%+v",
-		[]string{"error1",
+		[]string{"error1", `template: "error%d" args: [1]`,
 			"github.com/jxskiss/errors.TestFormatWithMessage\n" +
 				"\t.+/github.com/jxskiss/errors/format_test.go:278",
 			"error2"},
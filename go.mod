@@ -1,6 +1,13 @@
 module github.com/jxskiss/errors
 
+go 1.21
+
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/pkg/errors v0.9.1
-	github.com/stretchr/testify v1.3.0
+	github.com/sirupsen/logrus v1.9.4
+	github.com/stretchr/testify v1.12.1
+	go.yaml.in/yaml/v3 v3.0.5
 )
+
+require golang.org/x/sys v0.13.0 // indirect
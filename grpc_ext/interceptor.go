@@ -0,0 +1,98 @@
+package grpc_ext
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor returns a server interceptor which translates
+// errors returned by handlers to statuses by DefaultMapper.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return DefaultMapper.UnaryServerInterceptor()
+}
+
+// StreamServerInterceptor returns a server interceptor which translates
+// errors returned by handlers to statuses by DefaultMapper.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return DefaultMapper.StreamServerInterceptor()
+}
+
+// UnaryClientInterceptor returns a client interceptor which translates
+// statuses to errors by DefaultMapper.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return DefaultMapper.UnaryClientInterceptor()
+}
+
+// StreamClientInterceptor returns a client interceptor which translates
+// statuses to errors by DefaultMapper.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return DefaultMapper.StreamClientInterceptor()
+}
+
+// UnaryServerInterceptor returns a server interceptor which translates
+// errors returned by handlers to statuses, see ToStatus.
+//   server := grpc.NewServer(
+//           grpc.ChainUnaryInterceptor(mapper.UnaryServerInterceptor()),
+//           grpc.ChainStreamInterceptor(mapper.StreamServerInterceptor()),
+//   )
+func (m *Mapper) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, m.ToStatus(err).Err()
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor which translates
+// errors returned by handlers to statuses, see ToStatus.
+func (m *Mapper) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		if err != nil {
+			return m.ToStatus(err).Err()
+		}
+		return nil
+	}
+}
+
+// UnaryClientInterceptor returns a client interceptor which translates
+// statuses to errors, see FromStatus.
+//   conn, err := grpc.NewClient(target,
+//           grpc.WithChainUnaryInterceptor(mapper.UnaryClientInterceptor()),
+//           grpc.WithChainStreamInterceptor(mapper.StreamClientInterceptor()),
+//   )
+func (m *Mapper) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return m.fromError(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor returns a client interceptor which translates
+// statuses to errors, see FromStatus. Errors returned by the RecvMsg
+// method of the streams are translated too, except io.EOF.
+func (m *Mapper) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, m.fromError(err)
+		}
+		return &clientStream{ClientStream: cs, m: m}, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	m *Mapper
+}
+
+func (cs *clientStream) RecvMsg(msg interface{}) error {
+	err := cs.ClientStream.RecvMsg(msg)
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return cs.m.fromError(err)
+}
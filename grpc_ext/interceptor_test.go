@@ -0,0 +1,239 @@
+package grpc_ext
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// healthServer returns the error registered for the requested service.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	errs map[string]error
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if err := s.errs[req.Service]; err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	if err := s.errs[req.Service]; err != nil {
+		return err
+	}
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+// gatewayServer forwards the requests to a backend, the errors of the
+// backend are annotated by annotate.
+type gatewayServer struct {
+	healthpb.UnimplementedHealthServer
+	backend  healthpb.HealthClient
+	annotate func(err error) error
+}
+
+func (s *gatewayServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	resp, err := s.backend.Check(ctx, req)
+	if err != nil && s.annotate != nil {
+		err = s.annotate(err)
+	}
+	return resp, err
+}
+
+func newHealthClient(t *testing.T, serverMapper, clientMapper *Mapper, errs map[string]error) healthpb.HealthClient {
+	return dialHealthServer(t, serverMapper, clientMapper, &healthServer{errs: errs})
+}
+
+func dialHealthServer(t *testing.T, serverMapper, clientMapper *Mapper, srv healthpb.HealthServer) healthpb.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(serverMapper.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(serverMapper.StreamServerInterceptor()),
+	)
+	healthpb.RegisterHealthServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	opts := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if clientMapper != nil {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(clientMapper.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(clientMapper.StreamClientInterceptor()),
+		)
+	}
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+var testErrors = map[string]error{
+	"missing":  errors.Wrap(errors.NotFoundf("service %q", "missing"), "check", "service", "missing", "attempt", 2),
+	"slow":     errors.Timeoutf("backend"),
+	"canceled": errors.Wrap(context.Canceled, "check"),
+	"plain":    errors.New("boom"),
+	"public":   errors.WithPublicMessage(errors.New("connect to 10.0.0.1"), "try again later"),
+}
+
+func newServiceMapper() *Mapper {
+	m := NewMapper()
+	m.Fields = []string{"service"}
+	return m
+}
+
+func Test_Unary(t *testing.T) {
+	is := assert.New(t)
+	client := newHealthClient(t, newServiceMapper(), DefaultMapper, testErrors)
+	ctx := context.Background()
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "ok"})
+	is.Nil(err)
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	is.True(errors.IsNotFound(err))
+	is.Equal("not found", err.Error())
+	is.Equal(errors.F{"service": "missing"}, errors.Fields(err))
+	is.Equal(codes.NotFound, status.Code(err))
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "slow"})
+	is.True(errors.IsTimeout(err))
+	is.Equal(codes.DeadlineExceeded, status.Code(err))
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "canceled"})
+	is.Equal("", errors.KindOf(err))
+	is.Equal(codes.Canceled, status.Code(err))
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "plain"})
+	is.Equal("Unknown", err.Error())
+	is.Equal(codes.Unknown, status.Code(err))
+	is.Nil(errors.Fields(err))
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "public"})
+	is.Equal("try again later", err.Error())
+
+	// No field is sent unless it is allowed.
+	client = newHealthClient(t, DefaultMapper, DefaultMapper, testErrors)
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	is.Nil(errors.Fields(err))
+
+	// Messages and all fields of errors are sent in debug mode.
+	debug := NewMapper()
+	debug.DebugInfo = true
+	client = newHealthClient(t, debug, DefaultMapper, testErrors)
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	is.Equal(`check: service "missing" not found`, err.Error())
+	is.Equal(errors.F{"service": "missing", "attempt": "2"}, errors.Fields(err))
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "plain"})
+	is.Equal("boom", err.Error())
+}
+
+func Test_Gateway(t *testing.T) {
+	is := assert.New(t)
+	debug := NewMapper()
+	debug.DebugInfo = true
+	backend := newHealthClient(t, debug, DefaultMapper, testErrors)
+	gateway := &gatewayServer{backend: backend}
+	client := dialHealthServer(t, newServiceMapper(), DefaultMapper, gateway)
+	ctx := context.Background()
+
+	// The gateway does not reveal the messages, the stack traces and the
+	// fields which are sent by a backend in debug mode.
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	is.True(errors.IsNotFound(err))
+	is.Equal("not found", err.Error())
+	is.Equal(errors.F{"service": "missing"}, errors.Fields(err))
+	is.Nil(RemoteStack(err))
+	is.Equal(codes.NotFound, status.Code(err))
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "plain"})
+	is.Equal("Unknown", err.Error())
+	is.Equal(codes.Unknown, status.Code(err))
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "canceled"})
+	is.Equal(codes.Canceled, status.Code(err))
+
+	// The annotations of the gateway take precedence.
+	gateway.annotate = func(err error) error {
+		return errors.WithPublicMessage(errors.WithKind(err, "Forbidden"), "access denied")
+	}
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	is.True(errors.IsForbidden(err))
+	is.Equal("access denied", err.Error())
+	is.Equal(codes.PermissionDenied, status.Code(err))
+
+	// A gateway in debug mode forwards the statuses unchanged.
+	gateway.annotate = nil
+	client = dialHealthServer(t, debug, DefaultMapper, gateway)
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	is.Equal(`check: service "missing" not found`, err.Error())
+	is.NotNil(RemoteStack(err))
+}
+
+func Test_Stream(t *testing.T) {
+	is := assert.New(t)
+	client := newHealthClient(t, newServiceMapper(), DefaultMapper, testErrors)
+	ctx := context.Background()
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "ok"})
+	is.Nil(err)
+	resp, err := stream.Recv()
+	is.Nil(err)
+	is.Equal(healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	stream, err = client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "missing"})
+	is.Nil(err)
+	_, err = stream.Recv()
+	is.True(errors.IsNotFound(err))
+	is.Equal(errors.F{"service": "missing"}, errors.Fields(err))
+}
+
+func Test_CustomMapping(t *testing.T) {
+	is := assert.New(t)
+	server := newServiceMapper()
+	server.Codes["NotFound"] = codes.FailedPrecondition
+	server.Domain = "example.com"
+
+	// A client which does not use the interceptors sees the status.
+	client := newHealthClient(t, server, nil, testErrors)
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"})
+	st := status.Convert(err)
+	is.Equal(codes.FailedPrecondition, st.Code())
+	is.Len(st.Details(), 1)
+
+	// Details of other domains are ignored, the kind is then mapped
+	// from the code.
+	other := NewMapper()
+	other.Domain = "other.com"
+	err = other.FromStatus(st)
+	is.Equal("", errors.KindOf(err))
+	is.Nil(errors.Fields(err))
+	other.Kinds[codes.FailedPrecondition] = "NotProvisioned"
+	is.True(errors.IsNotProvisioned(other.FromStatus(st)))
+
+	err = server.FromStatus(st)
+	is.True(errors.IsNotFound(err))
+	is.Equal("missing", errors.Fields(err)["service"])
+
+	// Statuses are translated again, keeping the kind and the fields.
+	is.Equal(st, server.ToStatus(errors.Wrap(err, "proxy")))
+	is.Nil(server.ToStatus(nil))
+	is.Nil(server.FromStatus(nil))
+}
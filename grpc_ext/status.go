@@ -0,0 +1,382 @@
+// Package grpc_ext translates errors created by the errors package to gRPC
+// statuses at the server side, and statuses back to errors at the client
+// side, keeping the error kinds and the attached fields allowed by the
+// Mapper.
+package grpc_ext
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jxskiss/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// codeMetadataKey is the key of the code of an error, see
+// errors.CodeOf, in the metadata of an errdetails.ErrorInfo.
+const codeMetadataKey = "error_code"
+
+// DefaultMapper is used by the package level functions.
+var DefaultMapper = NewMapper()
+
+func init() {
+	errors.RegisterClassifier(classifyStatus)
+}
+
+// classifyStatus returns the kind mapped by the Kinds of DefaultMapper
+// from the code of the status carried by err, e.g. an error returned by
+// a gRPC client which is not translated by FromStatus, thus
+// errors.IsTimeout reports true for a codes.DeadlineExceeded status.
+func classifyStatus(err error) string {
+	if se, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+		return DefaultMapper.Kinds[se.GRPCStatus().Code()]
+	}
+	return ""
+}
+
+// Mapper translates between errors and gRPC statuses.
+//
+// The context of an error is sent to the client as details of the status,
+//
+//   - the kind, see errors.KindOf, and the attached fields allowed by
+//     Fields are sent as an errdetails.ErrorInfo, the kind as Reason and
+//     the fields as Metadata, the code, see errors.CodeOf, as the Metadata
+//     "error_code"
+//   - field violations, see errors.WithViolations, are sent as
+//     an errdetails.BadRequest
+//   - a retry hint is sent as an errdetails.RetryInfo, if errors.RetryAfter
+//     returns a positive duration, e.g. the error is marked by
+//     errors.Retryable
+//   - the stack trace is sent as an errdetails.DebugInfo, if DebugInfo
+//     is true
+type Mapper struct {
+	// Codes maps error kinds to status codes, errors of other kinds are
+	// translated to codes.Unknown. The GRPCCode of the definition of
+	// an error takes precedence, see errors.Definition.
+	Codes map[string]codes.Code
+
+	// Kinds maps status codes to error kinds, it is used for statuses
+	// which do not have an ErrorInfo detail, e.g. the ones sent by servers
+	// which do not use this package.
+	Kinds map[codes.Code]string
+
+	// Fields are the keys of the fields attached to errors which are sent
+	// to clients, other fields are only sent if DebugInfo is true.
+	Fields []string
+
+	// Domain is the Domain of the ErrorInfo details sent by ToStatus,
+	// FromStatus ignores ErrorInfo details of other domains.
+	// If it is empty, details of all domains are accepted.
+	Domain string
+
+	// DebugInfo tells whether the stack traces, the messages and all the
+	// fields of errors are sent to clients, instead of the public messages,
+	// see errors.PublicMessage, and the fields allowed by Fields.
+	// It should only be enabled for trusted clients, since stack traces,
+	// messages and fields reveal internals of the server.
+	DebugInfo bool
+}
+
+// NewMapper returns a Mapper with the default mapping.
+func NewMapper() *Mapper {
+	m := &Mapper{
+		Codes: map[string]codes.Code{
+			"Timeout":          codes.DeadlineExceeded,
+			"BadRequest":       codes.InvalidArgument,
+			"NotFound":         codes.NotFound,
+			"UserNotFound":     codes.NotFound,
+			"NotSupported":     codes.Unimplemented,
+			"NotValid":         codes.InvalidArgument,
+			"AlreadyExists":    codes.AlreadyExists,
+			"Unauthorized":     codes.Unauthenticated,
+			"Forbidden":        codes.PermissionDenied,
+			"NotImplemented":   codes.Unimplemented,
+			"NotProvisioned":   codes.FailedPrecondition,
+			"NotAssigned":      codes.FailedPrecondition,
+			"MethodNotAllowed": codes.PermissionDenied,
+		},
+		Kinds: map[codes.Code]string{
+			codes.DeadlineExceeded: "Timeout",
+			codes.InvalidArgument:  "BadRequest",
+			codes.NotFound:         "NotFound",
+			codes.AlreadyExists:    "AlreadyExists",
+			codes.Unauthenticated:  "Unauthorized",
+			codes.PermissionDenied: "Forbidden",
+			codes.Unimplemented:    "NotImplemented",
+		},
+	}
+	return m
+}
+
+// ToStatus translates err to a status by DefaultMapper.
+func ToStatus(err error) *status.Status {
+	return DefaultMapper.ToStatus(err)
+}
+
+// FromStatus translates st to an error by DefaultMapper.
+func FromStatus(st *status.Status) error {
+	return DefaultMapper.FromStatus(st)
+}
+
+// ToStatus translates err to a status. The cause context.Canceled is
+// translated to codes.Canceled, context.DeadlineExceeded is mapped by its
+// kind "Timeout".
+//
+// If an error in the chain of err carries a status, e.g. the error is
+// returned by a gRPC client, the status is returned as is if DebugInfo
+// is true. Else the status is translated like other errors, thus only the
+// public message and the fields allowed by Fields are sent, and the
+// kind and the public message annotated by the server take precedence
+// over those of the status. The code of the status is used if no code is
+// mapped from err.
+//
+// The message of the status is the public message of err, see
+// errors.PublicMessage, or the name of the code if err has none, unless
+// DebugInfo is true, then it is the message of err.
+//
+// If err is nil, ToStatus returns nil, which is an OK status.
+func (m *Mapper) ToStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+	downstream := findStatus(err)
+	if downstream != nil && m.DebugInfo {
+		return downstream
+	}
+
+	kind := errors.KindOf(err)
+	code, ok := definitionCode(err)
+	if !ok {
+		code, ok = m.Codes[kind]
+	}
+	if !ok && downstream != nil {
+		code, ok = downstream.Code(), true
+	}
+	if !ok {
+		code = codes.Unknown
+		if errors.Cause(err) == context.Canceled {
+			code = codes.Canceled
+		}
+	}
+	message := errors.PublicMessage(err)
+	if m.DebugInfo {
+		message = err.Error()
+	} else if message == "" {
+		message = code.String()
+	}
+	st := status.New(code, message)
+	if details := m.details(kind, err); len(details) > 0 {
+		if withDetails, derr := st.WithDetails(details...); derr == nil {
+			st = withDetails
+		}
+	}
+	return st
+}
+
+func (m *Mapper) details(kind string, err error) []protoadapt.MessageV1 {
+	var details []protoadapt.MessageV1
+	fields := errors.Fields(err)
+	if !m.DebugInfo {
+		allowed := make(errors.F, len(m.Fields))
+		for _, k := range m.Fields {
+			if v, ok := fields[k]; ok {
+				allowed[k] = v
+			}
+		}
+		fields = allowed
+	}
+	errCode := errors.CodeOf(err)
+	if kind != "" || len(fields) > 0 || errCode != "" {
+		info := &errdetails.ErrorInfo{Reason: kind, Domain: m.Domain}
+		if len(fields) > 0 || errCode != "" {
+			info.Metadata = make(map[string]string, len(fields)+1)
+			for k, v := range fields {
+				info.Metadata[k] = fieldString(v)
+			}
+			if errCode != "" {
+				info.Metadata[codeMetadataKey] = errCode
+			}
+		}
+		details = append(details, info)
+	}
+	if violations := errors.Violations(err); len(violations) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, v := range violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+		details = append(details, badRequest)
+	}
+	if delay := errors.RetryAfter(err); delay > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	}
+	if m.DebugInfo {
+		if st := errors.GetStackTracer(err); st != nil {
+			debug := &errdetails.DebugInfo{Detail: fmt.Sprintf("%+v", err)}
+			for _, frame := range st.StackTrace() {
+				debug.StackEntries = append(debug.StackEntries, fmt.Sprintf("%n (%s:%d)", frame, frame, frame))
+			}
+			details = append(details, debug)
+		}
+	}
+	return details
+}
+
+// FromStatus translates st to an error, the returned error is of the
+// kind sent by ToStatus, or the kind mapped by Kinds from the code of st
+// if there is no kind sent, and it carries the fields sent by ToStatus
+// as string values, and the field violations, see errors.Violations.
+// If the code sent by ToStatus is defined by errors.Define, the returned
+// error is wrapped by the definition, see errors.Definition.Wrap.
+// The returned error also carries st, thus status.FromError and
+// status.Code work with it.
+//
+// If st has a RetryInfo detail, the returned error has a method
+// "RetryAfter() time.Duration" which returns the retry delay, thus it is
+// retryable, see errors.IsRetryable and errors.RetryAfter.
+// The stack trace sent as a DebugInfo detail is returned by RemoteStack.
+//
+// If st is nil or OK, FromStatus returns nil.
+func (m *Mapper) FromStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	var (
+		kind       string
+		fields     map[string]string
+		violations []errors.FieldViolation
+		remote     = &statusError{st: st}
+		infoFound  bool
+	)
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			if infoFound || (m.Domain != "" && d.Domain != m.Domain) {
+				continue
+			}
+			kind, fields, infoFound = d.Reason, d.Metadata, true
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				violations = append(violations, errors.FieldViolation{
+					Field:       v.Field,
+					Description: v.Description,
+				})
+			}
+		case *errdetails.RetryInfo:
+			remote.retryDelay = d.RetryDelay.AsDuration()
+		case *errdetails.DebugInfo:
+			remote.stack = d.StackEntries
+		}
+	}
+	if kind == "" {
+		kind = m.Kinds[st.Code()]
+	}
+	var def *errors.Definition
+	if errCode, ok := fields[codeMetadataKey]; ok {
+		def, _ = errors.LookupDefinition(errCode)
+		fields = withoutKey(fields, codeMetadataKey)
+	}
+	err := errors.Kindf(kind, "%s", st.Message())
+	if len(fields) > 0 {
+		err = errors.With(err, fields)
+	}
+	if def != nil {
+		err = def.Wrap(err)
+	}
+	remote.error = errors.WithViolations(err, violations...)
+	return remote
+}
+
+// definitionCode returns the code given by the definition of err, see
+// errors.Definition.
+func definitionCode(err error) (codes.Code, bool) {
+	def, ok := errors.DefinitionOf(err)
+	if !ok || def.GRPCCode == "" {
+		return 0, false
+	}
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if c.String() == def.GRPCCode {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+func withoutKey(m map[string]string, key string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if k != key {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// fromError translates err to an error by FromStatus if it carries
+// a status.
+func (m *Mapper) fromError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if st, ok := status.FromError(err); ok {
+		return m.FromStatus(st)
+	}
+	return err
+}
+
+// findStatus returns the status carried by an error in the chain of err.
+func findStatus(err error) *status.Status {
+	type grpcStatus interface {
+		GRPCStatus() *status.Status
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if se, ok := e.(grpcStatus); ok {
+			return se.GRPCStatus()
+		}
+	}
+	return nil
+}
+
+func fieldString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return errors.FormatValue(v, errors.MaxFieldValueLen)
+}
+
+// RemoteStack returns the stack trace sent by a server as a DebugInfo
+// detail, see Mapper.DebugInfo, if err is returned by FromStatus or the
+// client interceptors.
+func RemoteStack(err error) []string {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if se, ok := e.(*statusError); ok {
+			return se.stack
+		}
+	}
+	return nil
+}
+
+// statusError is an error received from a gRPC server.
+type statusError struct {
+	error
+	st         *status.Status
+	retryDelay time.Duration
+	stack      []string
+}
+
+func (e *statusError) Cause() error               { return e.error }
+func (e *statusError) Unwrap() error              { return e.error }
+func (e *statusError) HasStack() bool             { return true }
+func (e *statusError) GRPCStatus() *status.Status { return e.st }
+func (e *statusError) RetryAfter() time.Duration  { return e.retryDelay }
+
+func (e *statusError) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, fmt.FormatString(s, verb), e.error)
+}
@@ -0,0 +1,157 @@
+package grpc_ext
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type throttledError struct {
+	delay time.Duration
+}
+
+func (e *throttledError) Error() string             { return "throttled" }
+func (e *throttledError) RetryAfter() time.Duration { return e.delay }
+
+func Test_Details(t *testing.T) {
+	is := assert.New(t)
+
+	invalid := errors.WithViolations(errors.BadRequestf("create user"),
+		errors.FieldViolation{Field: "email", Description: "must not be empty"},
+		errors.FieldViolation{Field: "age", Description: "must be positive"},
+	)
+	throttled := errors.Wrap(&throttledError{delay: 3 * time.Second}, "check", "tenant", "t1")
+
+	server := NewMapper()
+	server.DebugInfo = true
+	client := newHealthClient(t, server, DefaultMapper, map[string]error{
+		"invalid":   invalid,
+		"throttled": throttled,
+		"busy":      errors.Retryable(errors.New("busy"), 2*time.Second),
+	})
+	ctx := context.Background()
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "invalid"})
+	is.True(errors.IsBadRequest(err))
+	is.Equal([]errors.FieldViolation{
+		{Field: "email", Description: "must not be empty"},
+		{Field: "age", Description: "must be positive"},
+	}, errors.Violations(err))
+	stack := RemoteStack(err)
+	is.NotEmpty(stack)
+	is.True(strings.HasPrefix(stack[0], "Test_Details "), stack[0])
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "throttled"})
+	is.Equal(errors.F{"tenant": "t1"}, errors.Fields(err))
+	retry, ok := err.(interface{ RetryAfter() time.Duration })
+	is.True(ok)
+	is.Equal(3*time.Second, retry.RetryAfter())
+	is.True(errors.IsRetryable(err))
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "busy"})
+	is.True(errors.IsRetryable(err))
+	is.Equal(2*time.Second, errors.RetryAfter(err))
+}
+
+func Test_DebugInfoOptIn(t *testing.T) {
+	is := assert.New(t)
+
+	st := DefaultMapper.ToStatus(errors.NotFoundf("user"))
+	for _, d := range st.Details() {
+		_, ok := d.(*errdetails.DebugInfo)
+		is.False(ok)
+	}
+	is.Nil(RemoteStack(DefaultMapper.FromStatus(st)))
+
+	st = status.New(st.Code(), st.Message())
+	err := DefaultMapper.FromStatus(st)
+	is.True(errors.IsNotFound(err))
+	is.Nil(errors.Violations(err))
+	is.Equal(time.Duration(0), err.(interface{ RetryAfter() time.Duration }).RetryAfter())
+}
+
+func Test_ClassifyStatus(t *testing.T) {
+	is := assert.New(t)
+
+	err := status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	is.True(errors.IsTimeout(err))
+	is.True(errors.IsTimeout(errors.Annotate(err, "call")))
+	is.True(errors.IsNotFound(status.Error(codes.NotFound, "no such user")))
+	is.Equal("", errors.KindOf(status.Error(codes.Internal, "oops")))
+
+	is.Equal(codes.DeadlineExceeded, ToStatus(errors.Wrap(context.DeadlineExceeded, "call")).Code())
+	is.Equal(codes.Canceled, ToStatus(errors.Wrap(context.Canceled, "call")).Code())
+
+	// The kind sent by the server takes precedence over the code.
+	st := ToStatus(errors.UserNotFoundf("alice"))
+	is.Equal("UserNotFound", errors.KindOf(FromStatus(st)))
+}
+
+var errOutOfStock = errors.Define(errors.Definition{
+	Code:     "GRPC_OUT_OF_STOCK",
+	Kind:     "BadRequest",
+	GRPCCode: "FailedPrecondition",
+})
+
+func Test_Definition(t *testing.T) {
+	is := assert.New(t)
+
+	st := ToStatus(errors.Annotate(errOutOfStock.New("only 3 books left"), "order"))
+	is.Equal(codes.FailedPrecondition, st.Code())
+
+	err := FromStatus(st)
+	is.True(errOutOfStock.Is(err))
+	is.True(errors.IsBadRequest(err))
+	is.Nil(errors.Fields(err)["error_code"])
+}
+
+func Test_CyclicField(t *testing.T) {
+	is := assert.New(t)
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	mapper := NewMapper()
+	mapper.Fields = []string{"dump"}
+	st := mapper.ToStatus(errors.New("boom", "dump", m))
+	var info *errdetails.ErrorInfo
+	for _, d := range st.Details() {
+		if d, ok := d.(*errdetails.ErrorInfo); ok {
+			info = d
+		}
+	}
+	if is.NotNil(info) {
+		is.Equal("map[self:<cycle>]", info.Metadata["dump"])
+	}
+}
+
+func Test_ForwardedDebugInfo(t *testing.T) {
+	is := assert.New(t)
+
+	debug := NewMapper()
+	debug.DebugInfo = true
+	downstream := debug.ToStatus(errors.NotFoundf("user"))
+	hasDebugInfo := func(st *status.Status) bool {
+		for _, d := range st.Details() {
+			if _, ok := d.(*errdetails.DebugInfo); ok {
+				return true
+			}
+		}
+		return false
+	}
+	is.True(hasDebugInfo(downstream))
+
+	err := errors.Wrap(DefaultMapper.FromStatus(downstream), "proxy")
+	st := DefaultMapper.ToStatus(err)
+	is.False(hasDebugInfo(st))
+	is.Equal(downstream.Code(), st.Code())
+	is.Equal(len(downstream.Details())-1, len(st.Details()))
+	is.True(hasDebugInfo(debug.ToStatus(err)))
+}
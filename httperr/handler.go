@@ -0,0 +1,186 @@
+package httperr
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jxskiss/errors"
+)
+
+// HandlerFunc is an HTTP handler which returns an error.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Handler adapts fn to an http.Handler by DefaultMapper.
+func Handler(fn HandlerFunc) http.Handler {
+	return DefaultMapper.Handler(fn)
+}
+
+// Handler adapts fn to an http.Handler. An error returned by fn, or
+// a panic recovered from fn, is reported by Report, and written to the
+// client by WriteError, unless fn has already written the response
+// header. A panic of http.ErrAbortHandler is not recovered.
+//
+// In debug mode, if the client accepts HTML, e.g. a browser, an HTML
+// error page with the error chain, fields and stack trace is written
+// instead of problem details.
+//   http.Handle("/users/", httperr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+//           user, err := findUser(r.Context(), path.Base(r.URL.Path))
+//           if err != nil {
+//                   return errors.Wrap(err, "find user")
+//           }
+//           return json.NewEncoder(w).Encode(user)
+//   }))
+func (m *Mapper) Handler(fn HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tw := &trackingWriter{ResponseWriter: w}
+		err := m.serve(fn, tw, r)
+		if err == nil {
+			return
+		}
+		m.report(r, err)
+		if tw.wroteHeader {
+			return
+		}
+		if m.Debug && acceptsHTML(r) {
+			m.writeHTML(w, r, err)
+			return
+		}
+		m.WriteError(w, r, err)
+	})
+}
+
+func (m *Mapper) serve(fn HandlerFunc, w http.ResponseWriter, r *http.Request) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			err = recovered(p)
+		}
+	}()
+	return fn(w, r)
+}
+
+// recovered returns an error with a stack trace for a recovered panic.
+func recovered(p interface{}) error {
+	if err, ok := p.(error); ok {
+		return errors.WithMessage(errors.WithStack(err), "panic")
+	}
+	return errors.Errorf("panic: %v", p)
+}
+
+func (m *Mapper) report(r *http.Request, err error) {
+	if m.Report != nil {
+		m.Report(r, err)
+		return
+	}
+	if m.StatusCode(err) >= 500 {
+		log.Printf("httperr: %s %s: %+v", r.Method, r.URL.RequestURI(), err)
+	}
+}
+
+func acceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// trackingWriter tracks whether the response header is written.
+type trackingWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *trackingWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *trackingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+func (w *trackingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		w.wroteHeader = true
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("httperr: response writer does not support hijacking")
+}
+
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type debugLayer struct {
+	Type    string
+	Message string
+}
+
+type debugPage struct {
+	Problem    *Problem
+	Chain      []debugLayer
+	Fields     errors.F
+	Violations []errors.FieldViolation
+	Stack      string
+}
+
+var debugTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Problem.Status}} {{.Problem.Title}}</title></head>
+<body>
+<h1>{{.Problem.Status}} {{.Problem.Title}}</h1>
+<p>{{.Problem.Detail}}</p>
+<h2>Chain</h2>
+<ol>{{range .Chain}}
+<li><code>{{.Type}}</code> {{.Message}}</li>{{end}}
+</ol>
+{{- if .Fields}}
+<h2>Fields</h2>
+<table>{{range $k, $v := .Fields}}
+<tr><th>{{$k}}</th><td>{{printf "%v" $v}}</td></tr>{{end}}
+</table>
+{{- end}}
+{{- if .Violations}}
+<h2>Violations</h2>
+<ul>{{range .Violations}}
+<li><code>{{.Field}}</code> {{.Description}}</li>{{end}}
+</ul>
+{{- end}}
+{{- if .Stack}}
+<h2>Stack</h2>
+<pre>{{.Stack}}</pre>
+{{- end}}
+</body>
+</html>
+`))
+
+// writeHTML writes the debug error page.
+func (m *Mapper) writeHTML(w http.ResponseWriter, r *http.Request, err error) {
+	page := &debugPage{
+		Problem:    m.ToProblem(r, err),
+		Fields:     errors.Fields(err),
+		Violations: errors.Violations(err),
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		page.Chain = append(page.Chain, debugLayer{Type: fmt.Sprintf("%T", e), Message: e.Error()})
+	}
+	if st := errors.GetStackTracer(err); st != nil {
+		page.Stack = strings.TrimPrefix(fmt.Sprintf("%+v", st.StackTrace()), "\n")
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(page.Problem.Status)
+	debugTemplate.Execute(w, page)
+}
@@ -0,0 +1,134 @@
+package httperr
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type reported struct {
+	errs []error
+}
+
+func (r *reported) report(_ *http.Request, err error) {
+	r.errs = append(r.errs, err)
+}
+
+func serve(h http.Handler, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_Handler(t *testing.T) {
+	is := assert.New(t)
+	rep := &reported{}
+	m := NewMapper()
+	m.Report = rep.report
+
+	h := m.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.NotFoundf("user %d", 1)
+	})
+	rec := serve(h, "")
+	is.Equal(http.StatusNotFound, rec.Code)
+	is.Equal(ContentType, rec.Header().Get("Content-Type"))
+	var p Problem
+	is.Nil(json.Unmarshal(rec.Body.Bytes(), &p))
+	is.Equal("not found", p.Detail)
+	is.Len(rep.errs, 1)
+
+	// No error.
+	h = m.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("ok"))
+		return nil
+	})
+	rec = serve(h, "")
+	is.Equal(http.StatusOK, rec.Code)
+	is.Equal("ok", rec.Body.String())
+	is.Len(rep.errs, 1)
+
+	// The response is already started, the error is only reported.
+	h = m.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		return errors.New("late")
+	})
+	rec = serve(h, "")
+	is.Equal(http.StatusAccepted, rec.Code)
+	is.Equal("", rec.Body.String())
+	is.Len(rep.errs, 2)
+}
+
+func panicky() {
+	panic("boom")
+}
+
+func Test_HandlerPanic(t *testing.T) {
+	is := assert.New(t)
+	rep := &reported{}
+	m := NewMapper()
+	m.Report = rep.report
+
+	h := m.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		panicky()
+		return nil
+	})
+	rec := serve(h, "text/html")
+	is.Equal(http.StatusInternalServerError, rec.Code)
+	is.Equal(ContentType, rec.Header().Get("Content-Type"))
+	is.NotContains(rec.Body.String(), "boom")
+
+	is.Len(rep.errs, 1)
+	err := rep.errs[0]
+	is.Equal("panic: boom", err.Error())
+	is.Contains(errors.ErrorStack(err), "httperr.panicky")
+
+	h = m.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		panic(errors.NotFoundf("user"))
+	})
+	rec = serve(h, "")
+	is.Equal(http.StatusNotFound, rec.Code)
+
+	h = m.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		panic(http.ErrAbortHandler)
+	})
+	is.PanicsWithValue(http.ErrAbortHandler, func() { serve(h, "") })
+}
+
+func Test_HandlerDebugPage(t *testing.T) {
+	is := assert.New(t)
+	m := NewMapper()
+	m.Debug = true
+	m.Report = func(*http.Request, error) {}
+
+	h := m.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		err := errors.WithViolations(errors.NotValidf("<user>"),
+			errors.FieldViolation{Field: "email", Description: "must not be empty"})
+		return errors.Wrap(err, "create", "tenant", "t1")
+	})
+	rec := serve(h, "text/html,application/xhtml+xml")
+	is.Equal(http.StatusBadRequest, rec.Code)
+	is.Equal("text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"create: &lt;user&gt; not valid",
+		"<code>*errors.withType</code>",
+		"<th>tenant</th><td>t1</td>",
+		"<code>email</code> must not be empty",
+		"Test_HandlerDebugPage",
+	} {
+		is.True(strings.Contains(body, want), want)
+	}
+
+	// Clients which do not accept HTML get problem details.
+	rec = serve(h, "application/json")
+	is.Equal(ContentType, rec.Header().Get("Content-Type"))
+}
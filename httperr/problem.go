@@ -0,0 +1,451 @@
+// Package httperr translates errors created by the errors package to
+// RFC 7807 "application/problem+json" responses, and such responses back
+// to errors.
+package httperr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jxskiss/errors"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Names of the extension members written by ToProblem.
+const (
+	kindMember          = "kind"
+	codeMember          = "code"
+	invalidParamsMember = "invalid-params"
+	stackMember         = "stack"
+)
+
+// maxBodySize limits the size of the response bodies read by FromResponse.
+const maxBodySize = 1 << 20
+
+// Problem is a problem details object defined by RFC 7807.
+// Extensions are encoded as members of the object, members which are not
+// defined by RFC 7807 are decoded to Extensions.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	out := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		out[k] = v
+	}
+	setString := func(key, value string) {
+		if value != "" {
+			out[key] = value
+		}
+	}
+	setString("type", p.Type)
+	setString("title", p.Title)
+	setString("detail", p.Detail)
+	setString("instance", p.Instance)
+	if p.Status != 0 {
+		out["status"] = p.Status
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	*p = Problem{}
+	for k, raw := range members {
+		var err error
+		switch k {
+		case "type":
+			err = json.Unmarshal(raw, &p.Type)
+		case "title":
+			err = json.Unmarshal(raw, &p.Title)
+		case "status":
+			err = json.Unmarshal(raw, &p.Status)
+		case "detail":
+			err = json.Unmarshal(raw, &p.Detail)
+		case "instance":
+			err = json.Unmarshal(raw, &p.Instance)
+		default:
+			var v interface{}
+			err = json.Unmarshal(raw, &v)
+			if p.Extensions == nil {
+				p.Extensions = make(map[string]interface{})
+			}
+			p.Extensions[k] = v
+		}
+		if err != nil {
+			return errors.Wrapf(err, "decode problem member %q", k)
+		}
+	}
+	return nil
+}
+
+// invalidParam is a member of the "invalid-params" extension, as in the
+// examples of RFC 7807.
+type invalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// DefaultMapper is used by the package level functions.
+var DefaultMapper = NewMapper()
+
+// Mapper translates between errors and problem details.
+type Mapper struct {
+	// Statuses maps error kinds, see errors.KindOf, to HTTP status codes,
+	// errors of other kinds are translated to 500 Internal Server Error.
+	Statuses map[string]int
+
+	// Kinds maps HTTP status codes to error kinds, it is used for
+	// responses which do not tell the kind, e.g. the ones sent by servers
+	// which do not use this package.
+	Kinds map[int]string
+
+	// TypeBase is the prefix of the type URI of problems, the kind of the
+	// error is appended to it. If it is empty, the type is "about:blank".
+	TypeBase string
+
+	// Fields are the keys of the fields attached to errors which are
+	// written as extension members, other fields are never written.
+	// Numbers and booleans are written as is, other values are written as
+	// strings rendered by errors.FormatValue, bounded by
+	// errors.MaxFieldValueLen.
+	Fields []string
+
+	// Debug tells whether the stack traces and the messages of errors
+	// are written instead of the public messages, it should only be
+	// enabled in development.
+	// Handler also renders an HTML error page in debug mode.
+	Debug bool
+
+	// PublicMessage returns the detail of the problem of err, if it is
+	// nil, errors.PublicMessage is used, e.g. the RequestMessage method
+	// of a message catalog of package catalog renders messages in the
+	// locales accepted by the request. r may be nil.
+	PublicMessage func(r *http.Request, err error) string
+
+	// Report is called by Handler with the errors returned by handlers.
+	// If it is nil, errors with a server error status are logged by the
+	// standard log package.
+	Report func(r *http.Request, err error)
+}
+
+// NewMapper returns a Mapper with the default mapping.
+func NewMapper() *Mapper {
+	return &Mapper{
+		Statuses: map[string]int{
+			"Timeout":          http.StatusGatewayTimeout,
+			"BadRequest":       http.StatusBadRequest,
+			"NotFound":         http.StatusNotFound,
+			"UserNotFound":     http.StatusNotFound,
+			"NotSupported":     http.StatusBadRequest,
+			"NotValid":         http.StatusBadRequest,
+			"AlreadyExists":    http.StatusConflict,
+			"Unauthorized":     http.StatusUnauthorized,
+			"Forbidden":        http.StatusForbidden,
+			"NotImplemented":   http.StatusNotImplemented,
+			"NotProvisioned":   http.StatusPreconditionFailed,
+			"NotAssigned":      http.StatusPreconditionFailed,
+			"MethodNotAllowed": http.StatusMethodNotAllowed,
+		},
+		Kinds: map[int]string{
+			http.StatusBadRequest:       "BadRequest",
+			http.StatusUnauthorized:     "Unauthorized",
+			http.StatusForbidden:        "Forbidden",
+			http.StatusNotFound:         "NotFound",
+			http.StatusMethodNotAllowed: "MethodNotAllowed",
+			http.StatusRequestTimeout:   "Timeout",
+			http.StatusConflict:         "AlreadyExists",
+			http.StatusNotImplemented:   "NotImplemented",
+			http.StatusGatewayTimeout:   "Timeout",
+		},
+	}
+}
+
+// StatusCode returns the HTTP status code of err by DefaultMapper.
+func StatusCode(err error) int {
+	return DefaultMapper.StatusCode(err)
+}
+
+// WriteError writes err as problem details by DefaultMapper.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	DefaultMapper.WriteError(w, r, err)
+}
+
+// FromResponse translates an error response by DefaultMapper.
+func FromResponse(resp *http.Response) error {
+	return DefaultMapper.FromResponse(resp)
+}
+
+// StatusCode returns the HTTP status code of err, which is the HTTPStatus
+// of the definition of err, see errors.Definition, or mapped by Statuses
+// from the kind of err.
+// If err is nil, it returns 200 OK.
+func (m *Mapper) StatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if def, ok := errors.DefinitionOf(err); ok && def.HTTPStatus != 0 {
+		return def.HTTPStatus
+	}
+	if status, ok := m.Statuses[errors.KindOf(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// ToProblem translates err to problem details, r is the request which
+// failed, it may be nil.
+//
+// The detail is the public message of err, see PublicMessage, or the
+// message of err if Debug is true, thus internal messages are not
+// revealed. The kind of err is written as the extension member "kind",
+// the code, see errors.CodeOf, as "code", the field violations, see
+// errors.WithViolations, as "invalid-params", the allow-listed fields as
+// members with the field keys, and the stack trace as "stack" if Debug
+// is true.
+func (m *Mapper) ToProblem(r *http.Request, err error) *Problem {
+	status := m.StatusCode(err)
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	if r != nil && r.URL != nil {
+		p.Instance = r.URL.RequestURI()
+	}
+	if err == nil {
+		return p
+	}
+	switch {
+	case m.Debug:
+		p.Detail = err.Error()
+	case m.PublicMessage != nil:
+		p.Detail = m.PublicMessage(r, err)
+	default:
+		p.Detail = errors.PublicMessage(err)
+	}
+
+	ext := make(map[string]interface{})
+	if kind := errors.KindOf(err); kind != "" {
+		ext[kindMember] = kind
+		if m.TypeBase != "" {
+			p.Type = m.TypeBase + kind
+		}
+	}
+	if code := errors.CodeOf(err); code != "" {
+		ext[codeMember] = code
+	}
+	if violations := errors.Violations(err); len(violations) > 0 {
+		params := make([]invalidParam, len(violations))
+		for i, v := range violations {
+			params[i] = invalidParam{Name: v.Field, Reason: v.Description}
+		}
+		ext[invalidParamsMember] = params
+	}
+	if len(m.Fields) > 0 {
+		fields := errors.Fields(err)
+		for _, k := range m.Fields {
+			v, ok := fields[k]
+			if !ok {
+				continue
+			}
+			switch v.(type) {
+			case bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+				ext[k] = v
+			default:
+				ext[k] = errors.FormatValue(v, errors.MaxFieldValueLen)
+			}
+		}
+	}
+	if m.Debug {
+		if st := errors.GetStackTracer(err); st != nil {
+			var stack []string
+			for _, frame := range st.StackTrace() {
+				stack = append(stack, fmt.Sprintf("%n (%s:%d)", frame, frame, frame))
+			}
+			ext[stackMember] = stack
+		}
+	}
+	if len(ext) > 0 {
+		p.Extensions = ext
+	}
+	return p
+}
+
+// WriteError writes err as problem details to w, see ToProblem.
+// The header Retry-After is set if errors.RetryAfter returns a positive
+// duration.
+func (m *Mapper) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	p := m.ToProblem(r, err)
+	if after := errors.RetryAfter(err); after > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(int64((after+time.Second-1)/time.Second), 10))
+	}
+	body, merr := json.Marshal(p)
+	if merr != nil {
+		http.Error(w, http.StatusText(p.Status), p.Status)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	w.Write(body)
+}
+
+// FromResponse translates an error response to an error, it returns nil
+// if the status of resp is not an error, i.e. less than 400. The body of
+// resp is read if it is problem details, the caller should close it.
+//
+// The returned error is of the kind written by ToProblem, or the kind
+// mapped by Kinds from the status of resp, its message is the detail, or
+// the title if there is no detail. The extension members written by
+// ToProblem are restored as fields and field violations, and the error
+// is wrapped by the definition of the code if it is defined, see
+// errors.Definition.Wrap. The problem details are returned by ProblemOf.
+// If resp has the header Retry-After, the error is retryable, see
+// errors.Retryable.
+func (m *Mapper) FromResponse(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	p := &Problem{Status: resp.StatusCode, Title: http.StatusText(resp.StatusCode)}
+	if isProblem(resp.Header) {
+		decoded := &Problem{}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		if err == nil && json.Unmarshal(body, decoded) == nil {
+			p = decoded
+			if p.Status == 0 {
+				p.Status = resp.StatusCode
+			}
+		}
+	}
+	err := m.FromProblem(p)
+	if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		err = errors.Retryable(err, after)
+	}
+	return err
+}
+
+// parseRetryAfter parses the value of the header Retry-After, which is
+// either a number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		after := time.Until(t)
+		if after < 0 {
+			after = 0
+		}
+		return after, true
+	}
+	return 0, false
+}
+
+// FromProblem translates problem details to an error, see FromResponse.
+func (m *Mapper) FromProblem(p *Problem) error {
+	kind, _ := p.Extensions[kindMember].(string)
+	if kind == "" {
+		kind = m.Kinds[p.Status]
+	}
+	message := p.Detail
+	if message == "" {
+		message = p.Title
+	}
+	if message == "" {
+		message = fmt.Sprintf("status %d", p.Status)
+	}
+	err := errors.Kindf(kind, "%s", message)
+
+	keys := make([]string, 0, len(p.Extensions))
+	for k := range p.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var fields []interface{}
+	var violations []errors.FieldViolation
+	for _, k := range keys {
+		switch k {
+		case kindMember, codeMember, stackMember:
+		case invalidParamsMember:
+			violations = decodeInvalidParams(p.Extensions[k])
+		default:
+			fields = append(fields, k, p.Extensions[k])
+		}
+	}
+	err = errors.With(err, fields...)
+	err = errors.WithViolations(err, violations...)
+	if code, _ := p.Extensions[codeMember].(string); code != "" {
+		if def, ok := errors.LookupDefinition(code); ok {
+			err = def.Wrap(err)
+		}
+	}
+	return &problemError{error: err, problem: p}
+}
+
+func decodeInvalidParams(v interface{}) []errors.FieldViolation {
+	list, _ := v.([]interface{})
+	var violations []errors.FieldViolation
+	for _, x := range list {
+		param, _ := x.(map[string]interface{})
+		name, _ := param["name"].(string)
+		reason, _ := param["reason"].(string)
+		if name != "" || reason != "" {
+			violations = append(violations, errors.FieldViolation{Field: name, Description: reason})
+		}
+	}
+	return violations
+}
+
+// ProblemOf returns the problem details of an error returned by
+// FromResponse or FromProblem.
+func ProblemOf(err error) (*Problem, bool) {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if pe, ok := e.(*problemError); ok {
+			return pe.problem, true
+		}
+	}
+	return nil, false
+}
+
+// problemError is an error received as problem details.
+type problemError struct {
+	error
+	problem *Problem
+}
+
+func (e *problemError) Cause() error   { return e.error }
+func (e *problemError) Unwrap() error  { return e.error }
+func (e *problemError) HasStack() bool { return true }
+
+func (e *problemError) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, fmt.FormatString(s, verb), e.error)
+}
+
+// isProblem tells whether the media type of h is ContentType.
+func isProblem(h http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return strings.EqualFold(mediaType, ContentType)
+}
@@ -0,0 +1,212 @@
+package httperr
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newServer(t *testing.T, m *Mapper, err error) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.WriteError(w, r, err)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, url string) (*http.Response, map[string]interface{}) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var members map[string]interface{}
+	json.Unmarshal(body, &members)
+	resp.Body = io.NopCloser(strings.NewReader(string(body)))
+	return resp, members
+}
+
+func Test_WriteError(t *testing.T) {
+	is := assert.New(t)
+
+	err := errors.WithViolations(errors.NotValidf("user"),
+		errors.FieldViolation{Field: "email", Description: "must not be empty"})
+	err = errors.Wrap(err, "create user", "tenant", "t1", "password", "secret")
+
+	m := NewMapper()
+	m.Fields = []string{"tenant"}
+	m.TypeBase = "https://example.com/problems/"
+	server := newServer(t, m, err)
+
+	resp, members := get(t, server.URL+"/users?x=1")
+	is.Equal(http.StatusBadRequest, resp.StatusCode)
+	is.Equal(ContentType, resp.Header.Get("Content-Type"))
+	is.Equal(map[string]interface{}{
+		"type":     "https://example.com/problems/NotValid",
+		"title":    "Bad Request",
+		"status":   float64(400),
+		"detail":   "not valid",
+		"instance": "/users?x=1",
+		"kind":     "NotValid",
+		"tenant":   "t1",
+		"invalid-params": []interface{}{
+			map[string]interface{}{"name": "email", "reason": "must not be empty"},
+		},
+	}, members)
+
+	decoded := m.FromResponse(resp)
+	is.True(errors.IsNotValid(decoded))
+	is.Equal("not valid", decoded.Error())
+	is.Equal(errors.F{"tenant": "t1"}, errors.Fields(decoded))
+	is.Equal([]errors.FieldViolation{{Field: "email", Description: "must not be empty"}}, errors.Violations(decoded))
+	p, ok := ProblemOf(decoded)
+	is.True(ok)
+	is.Equal("/users?x=1", p.Instance)
+}
+
+func Test_WriteErrorFieldValues(t *testing.T) {
+	is := assert.New(t)
+
+	dump := map[string]interface{}{}
+	dump["self"] = dump
+	err := errors.New("boom", "dump", dump, "count", 3)
+
+	m := NewMapper()
+	m.Fields = []string{"dump", "count"}
+	_, members := get(t, newServer(t, m, err).URL)
+	is.Equal("map[self:<cycle>]", members["dump"])
+	is.Equal(float64(3), members["count"])
+}
+
+func Test_InternalError(t *testing.T) {
+	is := assert.New(t)
+
+	err := errors.New("connect to db at 10.0.0.1")
+	resp, members := get(t, newServer(t, NewMapper(), err).URL)
+	is.Equal(http.StatusInternalServerError, resp.StatusCode)
+	is.Equal(map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Internal Server Error",
+		"status":   float64(500),
+		"instance": "/",
+	}, members)
+
+	decoded := FromResponse(resp)
+	is.Equal("", errors.KindOf(decoded))
+	is.Equal("Internal Server Error", decoded.Error())
+
+	debug := NewMapper()
+	debug.Debug = true
+	_, members = get(t, newServer(t, debug, err).URL)
+	is.Equal("connect to db at 10.0.0.1", members["detail"])
+	stack, _ := members["stack"].([]interface{})
+	is.NotEmpty(stack)
+	is.True(strings.HasPrefix(stack[0].(string), "Test_InternalError "), stack[0])
+
+	public := errors.WithPublicMessage(err, "the service is unavailable")
+	_, members = get(t, newServer(t, NewMapper(), public).URL)
+	is.Equal("the service is unavailable", members["detail"])
+	_, members = get(t, newServer(t, debug, public).URL)
+	is.Equal("connect to db at 10.0.0.1", members["detail"])
+}
+
+func Test_FromResponse(t *testing.T) {
+	is := assert.New(t)
+
+	is.Nil(FromResponse(&http.Response{StatusCode: http.StatusOK}))
+
+	// Not problem details, the kind is mapped from the status.
+	resp := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       io.NopCloser(strings.NewReader("404 page not found")),
+	}
+	err := FromResponse(resp)
+	is.True(errors.IsNotFound(err))
+	is.Equal("Not Found", err.Error())
+
+	// Problem details written by another server.
+	resp = &http.Response{
+		StatusCode: http.StatusForbidden,
+		Header:     http.Header{"Content-Type": {"application/problem+json; charset=utf-8"}},
+		Body: io.NopCloser(strings.NewReader(`{"type":"https://example.com/probs/out-of-credit",
+			"title":"You do not have enough credit.","detail":"Your current balance is 30, but that costs 50.",
+			"balance":30}`)),
+	}
+	err = FromResponse(resp)
+	is.True(errors.IsForbidden(err))
+	is.Equal("Your current balance is 30, but that costs 50.", err.Error())
+	is.Equal(errors.F{"balance": float64(30)}, errors.Fields(err))
+	p, _ := ProblemOf(err)
+	is.Equal(http.StatusForbidden, p.Status)
+	is.Equal("https://example.com/probs/out-of-credit", p.Type)
+}
+
+func Test_RetryAfter(t *testing.T) {
+	is := assert.New(t)
+
+	w := httptest.NewRecorder()
+	WriteError(w, nil, errors.Retryable(errors.New("busy"), 1500*time.Millisecond))
+	is.Equal("2", w.Header().Get("Retry-After"))
+
+	resp := w.Result()
+	err := FromResponse(resp)
+	is.True(errors.IsRetryable(err))
+	is.Equal(2*time.Second, errors.RetryAfter(err))
+	_, ok := ProblemOf(err)
+	is.True(ok)
+
+	resp = &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+	after := errors.RetryAfter(FromResponse(resp))
+	is.True(after > 59*time.Minute && after <= time.Hour, after)
+
+	w = httptest.NewRecorder()
+	WriteError(w, nil, errors.New("oops"))
+	is.Equal("", w.Header().Get("Retry-After"))
+}
+
+func Test_StatusCode(t *testing.T) {
+	is := assert.New(t)
+	is.Equal(http.StatusOK, StatusCode(nil))
+	is.Equal(http.StatusMethodNotAllowed, StatusCode(errors.MethodNotAllowedf("DELETE")))
+	is.Equal(http.StatusNotFound, StatusCode(errors.Wrap(errors.NotFoundf("user"), "get")))
+	is.Equal(http.StatusInternalServerError, StatusCode(io.EOF))
+	is.Equal(http.StatusGatewayTimeout, StatusCode(errors.Wrap(context.DeadlineExceeded, "call")))
+}
+
+var errOutOfStock = errors.Define(errors.Definition{
+	Code:          "HTTPERR_OUT_OF_STOCK",
+	Kind:          "BadRequest",
+	HTTPStatus:    http.StatusConflict,
+	PublicMessage: "out of stock",
+})
+
+func Test_Definition(t *testing.T) {
+	is := assert.New(t)
+
+	err := errOutOfStock.New("only 3 books left")
+	is.Equal(http.StatusConflict, StatusCode(err))
+
+	resp, members := get(t, newServer(t, NewMapper(), err).URL)
+	is.Equal(http.StatusConflict, resp.StatusCode)
+	is.Equal("HTTPERR_OUT_OF_STOCK", members["code"])
+	is.Equal("out of stock", members["detail"])
+
+	err = FromResponse(resp)
+	is.True(errOutOfStock.Is(err))
+	is.True(errors.IsBadRequest(err))
+	is.Nil(errors.Fields(err)["code"])
+}
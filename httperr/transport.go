@@ -0,0 +1,158 @@
+package httperr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jxskiss/errors"
+)
+
+type attemptKey struct{}
+
+// ContextWithAttempt returns a copy of ctx which carries the number of
+// the attempt of a request, it is reported by Transport. Retrying clients
+// should set it for each attempt, starting with 1.
+func ContextWithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+func attemptFromContext(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// Transport is an http.RoundTripper which annotates failed requests.
+//
+// Transport failures, e.g. a refused connection or a timeout, are
+// annotated with a stack trace, and timeouts are of the kind "Timeout",
+// see errors.IsTimeout. The annotated failures keep the Timeout and
+// Temporary methods of net.Error, and the causes can be found by
+// errors.Is, e.g. context.DeadlineExceeded. Responses with a status other than 2xx are
+// closed and translated to errors by FromResponse, thus the kinds of the
+// errors are mapped from the statuses, e.g. 404 to "NotFound", or decoded
+// from problem details.
+//
+// The errors carry the fields "method", "url" with the password and the
+// query values redacted, "status" for error responses, "attempt", see
+// ContextWithAttempt, and "elapsed".
+//
+// Note that an error is returned for error responses, which differs from
+// the contract of http.RoundTripper, the errors returned by http.Client
+// wrap the errors returned by Transport. When the Timeout of http.Client
+// is exceeded, the client replaces the error returned by Transport, use
+// a context with a deadline to keep the annotations of timeouts.
+//   client := &http.Client{Transport: httperr.NewTransport(nil)}
+//   resp, err := client.Get(url)
+//   if errors.IsNotFound(err) {
+//           ...
+//   }
+type Transport struct {
+	// Base is the underlying RoundTripper, http.DefaultTransport is used
+	// if it is nil.
+	Base http.RoundTripper
+
+	// Mapper translates error responses, DefaultMapper is used if it
+	// is nil.
+	Mapper *Mapper
+}
+
+// NewTransport returns a Transport which wraps base.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	redacted := redactURL(req.URL)
+	fields := []interface{}{
+		errors.String("method", req.Method),
+		errors.String("url", redacted),
+		errors.Int("attempt", attemptFromContext(req.Context())),
+		errors.Duration("elapsed", elapsed),
+	}
+	if err != nil {
+		if ue, ok := err.(*url.Error); ok {
+			err = &url.Error{Op: ue.Op, URL: redacted, Err: ue.Err}
+		}
+		return nil, &transportError{
+			error: errors.WithStack(err, fields...),
+			cause: err,
+		}
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	m := t.Mapper
+	if m == nil {
+		m = DefaultMapper
+	}
+	err = m.FromResponse(resp)
+	if err == nil {
+		// A 1xx or 3xx response which is not handled by the client.
+		return resp, nil
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodySize))
+	resp.Body.Close()
+	fields = append(fields, errors.Int("status", resp.StatusCode))
+	return nil, errors.WithMessage(err, req.Method+" "+redacted, fields...)
+}
+
+// transportError is a transport failure annotated by Transport, it
+// implements the net.Error methods of the failure, thus the errors
+// returned by http.Client still report timeouts.
+type transportError struct {
+	error
+	cause error
+}
+
+func (e *transportError) Cause() error   { return e.error }
+func (e *transportError) Unwrap() error  { return e.error }
+func (e *transportError) HasStack() bool { return true }
+
+func (e *transportError) Timeout() bool {
+	t, ok := e.cause.(interface{ Timeout() bool })
+	return ok && t.Timeout()
+}
+
+func (e *transportError) Temporary() bool {
+	t, ok := e.cause.(interface{ Temporary() bool })
+	return ok && t.Temporary()
+}
+
+func (e *transportError) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, fmt.FormatString(s, verb), e.error)
+}
+
+// redactURL returns u with the password and the query values replaced.
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	c := *u
+	if c.RawQuery != "" {
+		query := c.Query()
+		for k, values := range query {
+			for i := range values {
+				values[i] = "xxxxx"
+			}
+			query[k] = values
+		}
+		c.RawQuery = query.Encode()
+	}
+	return c.Redacted()
+}
@@ -0,0 +1,124 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalJSON returns the JSON encoding of err. The result describes the
+// error message, the kind reported by KindOf, the code reported by CodeOf,
+// the attached fields and field violations (see WithViolations), and each
+// error in the chain with its own message, message template and arguments
+// (see MessageTemplater) and stack trace. Members of an ErrorGroup are
+// encoded recursively.
+//
+// Values of fields and message arguments which cannot be encoded as JSON,
+// or whose encoding is longer than MaxFieldValueLen, are encoded as
+// strings rendered like the "context:" block printed by "%+v".
+//
+// If err is nil, MarshalJSON returns "null".
+func MarshalJSON(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(newJSONError(err, false))
+}
+
+// MarshalRedactedJSON is like MarshalJSON, but unsafe parts of messages,
+// message arguments and field values are replaced by RedactionMark,
+// see Redacted and RedactValue.
+func MarshalRedactedJSON(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(newJSONError(err, true))
+}
+
+type jsonError struct {
+	Message    string                 `json:"message"`
+	Kind       string                 `json:"kind,omitempty"`
+	Code       string                 `json:"code,omitempty"`
+	Fields     map[string]interface{} `json:"fields,omitempty"`
+	Violations []FieldViolation       `json:"violations,omitempty"`
+	Chain      []*jsonLayer           `json:"chain"`
+}
+
+type jsonLayer struct {
+	Type     string        `json:"type"`
+	Message  string        `json:"message,omitempty"`
+	Template string        `json:"template,omitempty"`
+	Args     []interface{} `json:"args,omitempty"`
+	Stack    StackTrace    `json:"stack,omitempty"`
+	Errors   []*jsonError  `json:"errors,omitempty"`
+}
+
+func newJSONError(err error, redact bool) *jsonError {
+	out := &jsonError{
+		Message: err.Error(),
+		Kind:    KindOf(err),
+		Code:    CodeOf(err),
+	}
+	if redact {
+		out.Message = Redacted(err)
+	}
+	if fields := Fields(err); len(fields) > 0 {
+		out.Fields = make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			if redact {
+				v = RedactValue(v)
+			}
+			out.Fields[k] = jsonValue(v)
+		}
+	}
+	out.Violations = Violations(err)
+	for e := err; e != nil; e = Unwrap(e) {
+		out.Chain = append(out.Chain, newJSONLayer(e, redact))
+	}
+	return out
+}
+
+func newJSONLayer(err error, redact bool) *jsonLayer {
+	layer := &jsonLayer{Type: fmt.Sprintf("%T", err)}
+	switch err := err.(type) {
+	case *withStack, *withFields, *withViolations, annotator:
+	case *withMessage:
+		layer.Message = err.msg.String()
+		if redact {
+			layer.Message = err.msg.redacted()
+		}
+	case ErrorGroup:
+		for _, e := range err.Errors() {
+			layer.Errors = append(layer.Errors, newJSONError(e, redact))
+		}
+	default:
+		layer.Message = err.Error()
+		if redact {
+			layer.Message = Redacted(err)
+		}
+	}
+	if t, ok := err.(MessageTemplater); ok && len(t.MessageArgs()) > 0 {
+		layer.Template = t.MessageTemplate()
+		layer.Args = make([]interface{}, len(t.MessageArgs()))
+		for i, arg := range t.MessageArgs() {
+			if redact {
+				arg = RedactValue(arg)
+			}
+			layer.Args[i] = jsonValue(arg)
+		}
+	}
+	if st, ok := err.(StackTracer); ok {
+		layer.Stack = st.StackTrace()
+	}
+	return layer
+}
+
+// jsonValue returns the JSON encoding of v if it succeeds and is not
+// longer than MaxFieldValueLen, else the string representation of v,
+// bounded by MaxFieldValueLen.
+func jsonValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil || (MaxFieldValueLen > 0 && len(data) > MaxFieldValueLen) {
+		return FormatValue(v, MaxFieldValueLen)
+	}
+	return json.RawMessage(data)
+}
@@ -0,0 +1,104 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	is := assert.New(t)
+
+	data, err := MarshalJSON(nil)
+	is.Nil(err)
+	is.Equal("null", string(data))
+
+	e := NotFoundf("user %d", 42)
+	e = Wrapf(e, "load %s", "profile")
+	e = WithFields(e, F{"ch": make(chan int), "id": 42})
+	e = Append(e, io.EOF)
+	data, err = MarshalJSON(e)
+	is.Nil(err)
+
+	var got struct {
+		Message string
+		Chain   []struct {
+			Type   string
+			Errors []struct {
+				Message string
+				Kind    string
+				Fields  map[string]interface{}
+				Chain   []struct {
+					Type     string
+					Message  string
+					Template string
+					Args     []interface{}
+					Stack    []string
+				}
+			}
+		}
+	}
+	is.Nil(json.Unmarshal(data, &got))
+	is.Equal("load profile: user 42 not found; EOF", got.Message)
+	is.Len(got.Chain, 1)
+	is.Equal("errors.MultiError", got.Chain[0].Type)
+
+	members := got.Chain[0].Errors
+	is.Len(members, 2)
+	is.Equal("NotFound", members[0].Kind)
+	is.Equal(float64(42), members[0].Fields["id"])
+	is.IsType("", members[0].Fields["ch"])
+
+	chain := members[0].Chain
+	is.Len(chain, 3)
+	is.Equal("*errors.withFields", chain[0].Type)
+	is.Equal("*errors.withMessage", chain[1].Type)
+	is.Equal("load profile", chain[1].Message)
+	is.Equal("load %s", chain[1].Template)
+	is.Equal([]interface{}{"profile"}, chain[1].Args)
+	is.Equal("*errors.withType", chain[2].Type)
+	is.Equal("user %d not found", chain[2].Template)
+	is.Equal([]interface{}{float64(42)}, chain[2].Args)
+	is.NotEmpty(chain[2].Stack)
+	is.Contains(chain[2].Stack[0], "TestMarshalJSON")
+
+	is.Equal("EOF", members[1].Message)
+	is.Equal("*errors.errorString", members[1].Chain[0].Type)
+}
+
+func TestMessageTemplater(t *testing.T) {
+	is := assert.New(t)
+	tests := []struct {
+		err      error
+		template string
+		args     []interface{}
+	}{
+		{New("plain"), "plain", nil},
+		{Errorf("user %d", 1), "user %d", []interface{}{1}},
+		{NotFoundf("user %d", 1), "user %d not found", []interface{}{1}},
+		{Unwrap(Wrapf(io.EOF, "read %s", "body")), "read %s", []interface{}{"body"}},
+		{WithMessagef(io.EOF, "read %s", "body"), "read %s", []interface{}{"body"}},
+		{WithMessage(io.EOF, "read"), "read", nil},
+	}
+	for _, tt := range tests {
+		templater, ok := tt.err.(MessageTemplater)
+		if !is.True(ok, "%T", tt.err) {
+			continue
+		}
+		is.Equal(tt.template, templater.MessageTemplate())
+		is.Equal(tt.args, templater.MessageArgs())
+	}
+
+	err := Errorf("%d%%", 100)
+	is.Equal("100%", err.Error())
+
+	// The message is rendered when the error is created.
+	buf := []int{1, 2}
+	err = Errorf("items %v", buf)
+	buf[0] = 99
+	is.Equal("items [1 2]", err.Error())
+	is.Equal("%!(EXTRA int=1)", Errorf("", 1).Error())
+	is.Equal("", Errorf("", 1).(MessageTemplater).MessageTemplate())
+}
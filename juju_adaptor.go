@@ -26,22 +26,113 @@ const (
 	methodNotAllowed
 )
 
+// kindNames are the names of error types reported by KindOf.
+var kindNames = [...]string{
+	timeout:          "Timeout",
+	badRequest:       "BadRequest",
+	notFound:         "NotFound",
+	userNotFound:     "UserNotFound",
+	notSupported:     "NotSupported",
+	notValid:         "NotValid",
+	alreadyExists:    "AlreadyExists",
+	unauthorized:     "Unauthorized",
+	forbidden:        "Forbidden",
+	notImplemented:   "NotImplemented",
+	notProvisioned:   "NotProvisioned",
+	notAssigned:      "NotAssigned",
+	methodNotAllowed: "MethodNotAllowed",
+}
+
 func newTypedError(etype int, format string, args ...interface{}) error {
 	return &withType{
 		etype: etype,
 		fundamental: fundamental{
-			msg:   fmt.Sprintf(format, args...),
-			stack: callersSkip(5),
+			msg:   formatMessage(format, args),
+			stack: callersSkip(4),
 		},
 	}
 }
 
+// withKind annotates an error with an error type, see WithKind.
+type withKind struct {
+	annotation
+	etype int
+}
+
+// errorType returns the error type of the outermost error in the chain
+// of err which has one. If no error in the chain has a type given by this
+// package, the outermost error recognized by a Classifier is used.
+func errorType(err error) (int, bool) {
+	for e := err; e != nil; e = Unwrap(e) {
+		switch e := e.(type) {
+		case *withType:
+			return e.etype, true
+		case *withKind:
+			return e.etype, true
+		}
+	}
+	for e := err; e != nil; e = Unwrap(e) {
+		if etype, ok := classify(e); ok {
+			return etype, true
+		}
+	}
+	return 0, false
+}
+
 func isErrorType(err error, etype int) bool {
-	switch err := Cause(err).(type) {
-	case *withType:
-		return err.etype == etype
+	t, ok := errorType(err)
+	return ok && t == etype
+}
+
+// KindOf returns the name of the error type of err, e.g. "NotFound" for
+// errors created by NotFoundf, or an empty string if err was not created
+// by one of the juju adaptor functions or annotated by WithKind, and it
+// is not recognized by a Classifier, see RegisterClassifier.
+// If several errors in the chain of err have a type, the outermost one
+// is reported.
+func KindOf(err error) string {
+	if t, ok := errorType(err); ok {
+		return kindNames[t]
+	}
+	return ""
+}
+
+// Kinds returns the names of the known error types, which are reported
+// by KindOf and accepted by WithKind and Kindf.
+func Kinds() []string {
+	return append([]string(nil), kindNames[:]...)
+}
+
+// WithKind annotates err with the given kind, which is one of the names
+// reported by KindOf, e.g. WithKind(err, "Timeout") makes IsTimeout report
+// true for the returned error, while the message and the cause are kept.
+//
+// If err is nil, WithKind returns nil.
+// If kind is not a known name, WithKind returns the original err.
+func WithKind(err error, kind string) error {
+	if err == nil {
+		return nil
+	}
+	if etype, ok := kindType(kind); ok {
+		return &withKind{annotation: newAnnotation(err), etype: etype}
+	}
+	return err
+}
+
+// Kindf returns an error of the given kind, which is one of the names
+// reported by KindOf, e.g. Kindf("NotFound", "user %d", id) is the same as
+// NotFoundf("user %d", id), except that the kind is not appended to the
+// message. It is useful to restore errors received from other processes.
+//
+// If kind is not a known name, Kindf is the same as Errorf.
+func Kindf(kind string, format string, args ...interface{}) error {
+	if etype, ok := kindType(kind); ok {
+		return newTypedError(etype, format, args...)
+	}
+	return &fundamental{
+		msg:   formatMessage(format, args),
+		stack: callersSkip(3),
 	}
-	return false
 }
 
 // ==================== juju adaptor start ========================
@@ -1,6 +1,10 @@
 package errors
 
-import "testing"
+import (
+	"fmt"
+	"io"
+	"testing"
+)
 
 var jujuAdaptorTestcases = []struct {
 	maker   func(format string, args ...interface{}) error
@@ -29,3 +33,63 @@ func TestJujuAdaptor(t *testing.T) {
 		}
 	}
 }
+
+func TestKindOf(t *testing.T) {
+	if got := KindOf(Annotate(NotFoundf("user %d", 1), "query")); got != "NotFound" {
+		t.Errorf("KindOf: got %q, want %q", got, "NotFound")
+	}
+	if got := KindOf(New("plain")); got != "" {
+		t.Errorf("KindOf: got %q, want empty string", got)
+	}
+	for _, name := range kindNames {
+		if name == "" {
+			t.Errorf("missing kind name: %v", kindNames)
+		}
+	}
+}
+
+func TestKindf(t *testing.T) {
+	err := Kindf("NotFound", "user %d", 1)
+	if !IsNotFound(err) || err.Error() != "user 1" {
+		t.Errorf("Kindf: got %q of kind %q", err, KindOf(err))
+	}
+	err = Kindf("Unknown", "user %d", 1)
+	if KindOf(err) != "" || err.Error() != "user 1" {
+		t.Errorf("Kindf: got %q of kind %q", err, KindOf(err))
+	}
+	for _, err := range []error{Kindf("Timeout", "x"), Kindf("Unknown", "x")} {
+		frame := GetStackTracer(err).StackTrace()[0]
+		if got := fmt.Sprintf("%n", frame); got != "TestKindf" {
+			t.Errorf("Kindf: first frame = %s, want TestKindf", got)
+		}
+	}
+}
+
+func TestWithKind(t *testing.T) {
+	if WithKind(nil, "Timeout") != nil {
+		t.Error("WithKind(nil): want nil")
+	}
+	if err := WithKind(io.EOF, "Unknown"); err != io.EOF {
+		t.Errorf("WithKind with unknown kind: got %v, want io.EOF", err)
+	}
+
+	err := WithKind(Wrap(io.EOF, "read"), "Timeout")
+	if !IsTimeout(err) || KindOf(err) != "Timeout" {
+		t.Errorf("WithKind: got kind %q", KindOf(err))
+	}
+	if err.Error() != "read: EOF" || Cause(err) != io.EOF || !HasStack(err) {
+		t.Errorf("WithKind: got %q with cause %v", err, Cause(err))
+	}
+	if got := fmt.Sprintf("%+v", err); got != fmt.Sprintf("%+v", Unwrap(err)) {
+		t.Errorf("WithKind: got %q", got)
+	}
+
+	// The outermost kind wins.
+	err = WithKind(Wrap(NotFoundf("user"), "get"), "Forbidden")
+	if !IsForbidden(err) || IsNotFound(err) {
+		t.Errorf("WithKind: got kind %q", KindOf(err))
+	}
+	if got := Redacted(WithKind(NotFoundf("user %d", 1), "Forbidden")); got != "user ‹×› not found" {
+		t.Errorf("Redacted: got %q", got)
+	}
+}
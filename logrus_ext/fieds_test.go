@@ -4,6 +4,7 @@ import (
 	"bytes"
 	stderr "errors"
 	"io/ioutil"
+	"strings"
 	"sync"
 	"testing"
 
@@ -114,3 +115,101 @@ func errForBenchmark() error {
 	})
 	return err
 }
+
+func Test_Redact(t *testing.T) {
+	var b bytes.Buffer
+	var logger = logrus.New()
+	logger.Out = &b
+	logger.Formatter = &logrus.JSONFormatter{}
+	hook := NewErrFieldsHook("err_")
+	hook.Redact = true
+	logger.AddHook(hook)
+	logger.AddHook(NewStacktraceHook())
+
+	err := errors.Errorf("login failed for %s", "alice@example.com")
+	err = errors.WithFields(err, errors.F{"token": "t0ps3cret", "attempt": errors.Safe(3)})
+	logger.WithError(err).Error("test redact")
+
+	out := b.String()
+	for _, secret := range []string{"alice@example.com", "t0ps3cret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("unexpected %q in log output: %s", secret, out)
+		}
+	}
+	for _, want := range []string{`"error":"login failed for ‹×›"`, `"err_attempt":3`, `"err_token":"‹×›"`, `"stacktrace":`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in log output: %s", want, out)
+		}
+	}
+}
+
+func Test_MaskPolicy(t *testing.T) {
+	var b bytes.Buffer
+	var logger = logrus.New()
+	logger.Out = &b
+	logger.Formatter = &logrus.JSONFormatter{}
+	hook := NewErrFieldsHook("")
+	hook.MaskPolicy = errors.MaskPolicy{
+		{Pattern: "*password", Action: errors.DropField},
+		{Pattern: "token", Action: errors.MaskValue},
+	}
+	logger.AddHook(hook)
+
+	err := errors.WithFields(errors.New("login failed"), errors.F{
+		"user":          "alice",
+		"token":         "t0ps3cret",
+		"user_password": "hunter2",
+	})
+	logger.WithError(err).Error("test mask policy")
+
+	out := b.String()
+	for _, secret := range []string{"t0ps3cret", "hunter2", "user_password"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("unexpected %q in log output: %s", secret, out)
+		}
+	}
+	for _, want := range []string{`"user":"alice"`, `"token":"******"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in log output: %s", want, out)
+		}
+	}
+	if got := errors.MaskedFields(err, errors.MaskPolicy{})["token"]; got != "t0ps3cret" {
+		t.Errorf("hook must not modify the error fields, got %v", got)
+	}
+}
+
+func Test_Severity(t *testing.T) {
+	var b bytes.Buffer
+	var logger = logrus.New()
+	logger.Out = &b
+	logger.Formatter = &logrus.JSONFormatter{}
+	logger.AddHook(NewSeverityHook())
+	stackHook := NewStacktraceHook()
+	stackHook.MinSeverity = errors.Error
+	logger.AddHook(stackHook)
+
+	tests := []struct {
+		err   error
+		level logrus.Level
+		want  []string
+		stack bool
+	}{
+		{errors.NotFoundf("user"), logrus.ErrorLevel, []string{`"level":"warning"`, `"severity":"warning"`}, false},
+		{errors.New("oops"), logrus.WarnLevel, []string{`"level":"error"`, `"severity":"error"`}, true},
+		{errors.WithSeverity(errors.New("oops"), errors.Critical), logrus.ErrorLevel, []string{`"level":"error"`, `"severity":"critical"`}, true},
+		{errors.New("oops"), logrus.InfoLevel, []string{`"level":"info"`}, true},
+	}
+	for _, tt := range tests {
+		b.Reset()
+		logger.WithError(tt.err).Log(tt.level, "test severity")
+		out := b.String()
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("missing %q in log output: %s", want, out)
+			}
+		}
+		if got := strings.Contains(out, `"stacktrace":`); got != tt.stack {
+			t.Errorf("stacktrace attached: got %v, want %v: %s", got, tt.stack, out)
+		}
+	}
+}
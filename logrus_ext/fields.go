@@ -9,12 +9,24 @@ import (
 // error with the entry, if the error or it's cause error has attached fields,
 // (created by methods in the errors package), then the extra fields
 // attached with the error object will be appended to the log entry.
+//
+// The fields are masked by the global errors.MaskPolicy, set MaskPolicy
+// to apply a different policy for this hook.
+//
+// To keep unsafe information out of logs, set Redact to true, then
+// unsafe field values are replaced and the error attached with the entry
+// is replaced by one with redacted message, see errors.Redacted.
+//   hook := NewErrFieldsHook("err_")
+//   hook.Redact = true
+//   logrus.AddHook(hook)
 func NewErrFieldsHook(keyPrefix string) *errFieldsHook {
 	return &errFieldsHook{prefix: keyPrefix}
 }
 
 type errFieldsHook struct {
-	prefix string
+	prefix     string
+	Redact     bool
+	MaskPolicy errors.MaskPolicy
 }
 
 func (hook *errFieldsHook) Levels() []logrus.Level {
@@ -33,15 +45,20 @@ func (hook *errFieldsHook) Fire(entry *logrus.Entry) error {
 	if !ok || err == nil {
 		return nil
 	}
-	fields := errors.Fields(err)
-	if hook.prefix != "" {
-		for k, v := range fields {
-			entry.Data[hook.prefix+k] = v
-		}
+	var fields errors.F
+	if hook.MaskPolicy != nil {
+		fields = errors.MaskedFields(err, hook.MaskPolicy)
 	} else {
-		for k, v := range fields {
-			entry.Data[k] = v
+		fields = errors.Fields(err)
+	}
+	if hook.Redact {
+		entry.Data[logrus.ErrorKey] = errors.RedactedError(err)
+	}
+	for k, v := range fields {
+		if hook.Redact {
+			v = errors.RedactValue(v)
 		}
+		entry.Data[hook.prefix+k] = v
 	}
 	return nil
 }
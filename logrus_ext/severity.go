@@ -0,0 +1,66 @@
+package logrus_ext
+
+import (
+	"github.com/jxskiss/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const severityKey = "severity"
+
+// NewSeverityHook returns a new logrus hook which will check attached
+// error with the entry, and rewrite the level of the entry from the
+// severity of the error, see errors.SeverityOf, e.g. an error of the kind
+// "NotFound" logged by Error is logged at the warning level.
+// The severity is also attached to the entry using SeverityKey.
+//
+// Only entries of RewriteLevels are rewritten, which are the error and
+// warning levels by default. Severities are mapped to levels by
+// SeverityLevels, errors.Critical is mapped to the error level by default,
+// since logrus exits or panics only by the functions called, not by the
+// level of the entry.
+//   hook := NewSeverityHook()
+//   hook.SeverityLevels[errors.Critical] = logrus.FatalLevel
+//   logrus.AddHook(hook)
+//
+// Note that logrus chooses the hooks to fire by the level an entry is
+// logged at, before any hook fires. To attach stack traces by severity,
+// see the MinSeverity of the stacktrace hook.
+func NewSeverityHook() *severityHook {
+	return &severityHook{
+		SeverityKey: severityKey,
+		SeverityLevels: map[errors.Severity]logrus.Level{
+			errors.Warning:  logrus.WarnLevel,
+			errors.Error:    logrus.ErrorLevel,
+			errors.Critical: logrus.ErrorLevel,
+		},
+		RewriteLevels: []logrus.Level{
+			logrus.ErrorLevel,
+			logrus.WarnLevel,
+		},
+	}
+}
+
+type severityHook struct {
+	SeverityKey    string
+	SeverityLevels map[errors.Severity]logrus.Level
+	RewriteLevels  []logrus.Level
+}
+
+func (hook *severityHook) Levels() []logrus.Level {
+	return hook.RewriteLevels
+}
+
+func (hook *severityHook) Fire(entry *logrus.Entry) error {
+	err, ok := entry.Data[logrus.ErrorKey].(error)
+	if !ok || err == nil {
+		return nil
+	}
+	severity := errors.SeverityOf(err)
+	if level, ok := hook.SeverityLevels[severity]; ok {
+		entry.Level = level
+	}
+	if hook.SeverityKey != "" {
+		entry.Data[hook.SeverityKey] = severity.String()
+	}
+	return nil
+}
@@ -22,6 +22,10 @@ const stacktraceKey = "stacktrace"
 //   hook.StackLevels = []logrus.Level{logrus.PanicLevel, logrus.FatalLevel}
 //   logrus.AddHook(hook)
 //
+// To attach stacktraces only for errors which are serious enough, set
+// MinSeverity, e.g. errors.Error skips the errors of kinds like "NotFound",
+// see errors.SeverityOf.
+//
 func NewStacktraceHook() *stacktraceHook {
 	return &stacktraceHook{
 		StacktraceKey: stacktraceKey,
@@ -39,6 +43,7 @@ func NewStacktraceHook() *stacktraceHook {
 type stacktraceHook struct {
 	StacktraceKey string
 	StackLevels   []logrus.Level
+	MinSeverity   errors.Severity
 }
 
 func (hook *stacktraceHook) Levels() []logrus.Level {
@@ -50,6 +55,9 @@ func (hook *stacktraceHook) Fire(entry *logrus.Entry) error {
 	if !ok || err == nil {
 		return nil
 	}
+	if hook.MinSeverity > 0 && errors.SeverityOf(err) < hook.MinSeverity {
+		return nil
+	}
 	stackTracker := errors.GetStackTracer(err)
 	if stackTracker == nil {
 		return nil
@@ -0,0 +1,156 @@
+package errors
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"strings"
+	"sync/atomic"
+)
+
+// MaskedValue replaces field values masked by MaskValue.
+const MaskedValue = "******"
+
+// MaskAction tells how the value of a field matched by a MaskRule is
+// treated.
+type MaskAction int
+
+const (
+	// MaskValue replaces the value with MaskedValue.
+	MaskValue MaskAction = iota
+	// HashValue replaces the value with a keyed hash of it, which allows
+	// correlating values without revealing them, see MaskRule.Key.
+	HashValue
+	// DropField removes the field.
+	DropField
+)
+
+// MaskRule matches field keys by Pattern, and applies Action to the
+// values of the matched fields.
+//
+// Pattern is either a key name, e.g. "password", or a pattern in the
+// syntax of path.Match, e.g. "*_secret". Keys are matched
+// case-insensitively.
+type MaskRule struct {
+	Pattern string
+	Action  MaskAction
+
+	// Key is the secret key of the HMAC-SHA256 which HashValue replaces
+	// values with, the value is rendered by FormatValue and bounded by
+	// MaxFieldValueLen before it is hashed. Without the key, short values
+	// such as PINs or tokens cannot be recovered from the hashes by brute
+	// force, thus it should be kept secret, e.g. loaded from the secret
+	// store of the service, and shared only by the services whose logs
+	// need to be correlated.
+	//
+	// If Key is empty, a random key generated at program start is used,
+	// the hashes can then only be correlated within one process.
+	Key []byte
+}
+
+// defaultMaskKey is the key of HashValue for rules without a Key.
+var defaultMaskKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("errors: generate mask key: " + err.Error())
+	}
+	return key
+}()
+
+func (r MaskRule) hash(value interface{}) string {
+	key := r.Key
+	if len(key) == 0 {
+		key = defaultMaskKey
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(FormatValue(value, MaxFieldValueLen)))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+func (r MaskRule) match(key string) bool {
+	pattern := strings.ToLower(r.Pattern)
+	key = strings.ToLower(key)
+	if pattern == key {
+		return true
+	}
+	matched, _ := path.Match(pattern, key)
+	return matched
+}
+
+// MaskPolicy is a list of MaskRule, the first rule which matches a field
+// key is applied to the field.
+//
+// The global policy set by SetMaskPolicy applies when the "context:"
+// block of an error is printed by "%+v", and when the fields are exported
+// by Fields. A different policy can be applied by MaskedFields, e.g. the
+// logrus hook in package logrus_ext accepts its own policy.
+type MaskPolicy []MaskRule
+
+// Apply returns a copy of fields with the policy applied.
+// If fields is nil, Apply returns nil.
+func (p MaskPolicy) Apply(fields F) F {
+	if fields == nil {
+		return nil
+	}
+	out := make(F, len(fields))
+	for k, v := range fields {
+		if v, ok := p.apply(k, v); ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// apply returns the value to report for key, the returned bool is false
+// if the field should be dropped.
+func (p MaskPolicy) apply(key string, value interface{}) (interface{}, bool) {
+	for _, rule := range p {
+		if !rule.match(key) {
+			continue
+		}
+		switch rule.Action {
+		case HashValue:
+			return rule.hash(value), true
+		case DropField:
+			return nil, false
+		default:
+			return MaskedValue, true
+		}
+	}
+	return value, true
+}
+
+var globalMaskPolicy atomic.Value
+
+// SetMaskPolicy sets the global MaskPolicy, it is safe to be called
+// concurrently. A nil policy disables masking, which is the default.
+//
+// Example:
+//
+//     errors.SetMaskPolicy(errors.MaskPolicy{
+//             {Pattern: "password", Action: errors.DropField},
+//             {Pattern: "token", Action: errors.MaskValue},
+//             {Pattern: "*_secret", Action: errors.HashValue, Key: maskKey},
+//     })
+func SetMaskPolicy(p MaskPolicy) {
+	globalMaskPolicy.Store(p)
+}
+
+// GetMaskPolicy returns the global MaskPolicy.
+func GetMaskPolicy() MaskPolicy {
+	p, _ := globalMaskPolicy.Load().(MaskPolicy)
+	return p
+}
+
+// MaskedFields returns the attached fields of err with the given policy
+// applied instead of the global policy.
+// Note that an empty policy returns the fields unmasked.
+func MaskedFields(err error, policy MaskPolicy) F {
+	fields := rawFields(err)
+	if len(policy) == 0 {
+		return fields
+	}
+	return policy.Apply(fields)
+}
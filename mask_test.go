@@ -0,0 +1,68 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskPolicy(t *testing.T) {
+	is := assert.New(t)
+	policy := MaskPolicy{
+		{Pattern: "password", Action: DropField},
+		{Pattern: "token", Action: MaskValue},
+		{Pattern: "*_secret", Action: HashValue},
+	}
+	is.Nil(policy.Apply(nil))
+
+	got := policy.Apply(F{"Password": "p", "TOKEN": "t", "api_secret": "s", "user": "u"})
+	is.Len(got, 3)
+	is.Equal(MaskedValue, got["TOKEN"])
+	is.Equal("u", got["user"])
+	is.True(strings.HasPrefix(got["api_secret"].(string), "hmac:"))
+	is.Equal(got["api_secret"], policy.Apply(F{"db_secret": "s"})["db_secret"])
+	is.NotEqual(got["api_secret"], policy.Apply(F{"db_secret": "t"})["db_secret"])
+}
+
+func TestMaskHashKey(t *testing.T) {
+	is := assert.New(t)
+	rule := MaskRule{Pattern: "pin", Action: HashValue, Key: []byte("k1")}
+	h1 := MaskPolicy{rule}.Apply(F{"pin": 1234})["pin"]
+
+	// HMAC-SHA256("k1", "1234") truncated to 8 bytes.
+	is.Equal("hmac:6df50f9f4b9dc05c", h1)
+	is.Equal(h1, MaskPolicy{rule}.Apply(F{"pin": "1234"})["pin"])
+
+	rule.Key = []byte("k2")
+	is.NotEqual(h1, MaskPolicy{rule}.Apply(F{"pin": 1234})["pin"])
+
+	// Cyclic values are hashed safely.
+	m := map[string]interface{}{}
+	m["self"] = m
+	is.True(strings.HasPrefix(MaskPolicy{rule}.Apply(F{"pin": m})["pin"].(string), "hmac:"))
+}
+
+func TestGlobalMaskPolicy(t *testing.T) {
+	is := assert.New(t)
+	defer SetMaskPolicy(nil)
+
+	err := WithFields(io.EOF, F{"password": "p", "token": "t", "user": "u"})
+	SetMaskPolicy(MaskPolicy{
+		{Pattern: "password", Action: DropField},
+		{Pattern: "token", Action: MaskValue},
+	})
+	is.Equal(F{"token": MaskedValue, "user": "u"}, Fields(err))
+	is.Equal("EOF\ncontext: token=\"******\" user=u", fmt.Sprintf("%+v", err))
+	is.Equal(F{"password": "p", "token": "t", "user": "u"}, MaskedFields(err, MaskPolicy{}))
+
+	// Masking does not affect fields attached by later calls.
+	err = WithFields(err, F{"extra": 1})
+	is.Equal(F{"password": "p", "token": "t", "user": "u", "extra": 1}, MaskedFields(err, MaskPolicy{}))
+
+	SetMaskPolicy(nil)
+	is.Nil(GetMaskPolicy())
+	is.Equal("p", Fields(err)["password"])
+}
@@ -0,0 +1,131 @@
+// Package otel_ext records errors created by the errors package to
+// OpenTelemetry spans.
+package otel_ext
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jxskiss/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys of the exception event, as defined by the OpenTelemetry
+// semantic conventions.
+const (
+	exceptionEvent      = "exception"
+	exceptionType       = attribute.Key("exception.type")
+	exceptionMessage    = attribute.Key("exception.message")
+	exceptionStacktrace = attribute.Key("exception.stacktrace")
+)
+
+// RecordError records err to span, and sets the status of span to
+// codes.Error with the message of err.
+//
+// An "exception" event is added with the attributes exception.type,
+// which is the kind reported by errors.KindOf or the type of the cause
+// of err, exception.message, and exception.stacktrace if err has a stack
+// trace, see errors.GetStackTracer. If the chain of err contains an
+// errors.ErrorGroup, e.g. an errors.MultiError, an event is added for
+// each member of the group instead.
+//
+// The fields attached to err, as returned by errors.Fields, are set as
+// attributes of span.
+//
+// If err is nil or span is not recording, RecordError does nothing.
+//   if err != nil {
+//           otel_ext.RecordError(span, err)
+//   }
+func RecordError(span trace.Span, err error) {
+	if err == nil || span == nil || !span.IsRecording() {
+		return
+	}
+	if group := findGroup(err); group != nil {
+		for _, e := range group.Errors() {
+			if e != nil {
+				span.AddEvent(exceptionEvent, trace.WithAttributes(exceptionAttributes(e)...))
+			}
+		}
+	} else {
+		span.AddEvent(exceptionEvent, trace.WithAttributes(exceptionAttributes(err)...))
+	}
+	if attrs := fieldAttributes(errors.Fields(err)); len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// findGroup returns the first ErrorGroup in the chain of err.
+func findGroup(err error) errors.ErrorGroup {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if group, ok := e.(errors.ErrorGroup); ok {
+			return group
+		}
+	}
+	return nil
+}
+
+func exceptionAttributes(err error) []attribute.KeyValue {
+	typ := errors.KindOf(err)
+	if typ == "" {
+		typ = fmt.Sprintf("%T", errors.Cause(err))
+	}
+	attrs := []attribute.KeyValue{
+		exceptionType.String(typ),
+		exceptionMessage.String(err.Error()),
+	}
+	if st := errors.GetStackTracer(err); st != nil {
+		attrs = append(attrs, exceptionStacktrace.String(fmt.Sprintf("%+v", st.StackTrace())))
+	}
+	return attrs
+}
+
+// fieldAttributes converts fields to attributes ordered by key.
+func fieldAttributes(fields errors.F) []attribute.KeyValue {
+	if len(fields) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, fieldAttribute(k, fields[k]))
+	}
+	return attrs
+}
+
+func fieldAttribute(key string, value interface{}) attribute.KeyValue {
+	k := attribute.Key(key)
+	switch v := value.(type) {
+	case string:
+		return k.String(v)
+	case bool:
+		return k.Bool(v)
+	case int:
+		return k.Int(v)
+	case int64:
+		return k.Int64(v)
+	case int32:
+		return k.Int64(int64(v))
+	case float64:
+		return k.Float64(v)
+	case float32:
+		return k.Float64(float64(v))
+	case []string:
+		return k.StringSlice(v)
+	case []int:
+		return k.IntSlice(v)
+	case []int64:
+		return k.Int64Slice(v)
+	case []float64:
+		return k.Float64Slice(v)
+	case []bool:
+		return k.BoolSlice(v)
+	}
+	return k.String(errors.FormatValue(value, errors.MaxFieldValueLen))
+}
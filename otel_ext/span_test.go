@@ -0,0 +1,93 @@
+package otel_ext
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpan(t *testing.T, err error) sdktrace.ReadOnlySpan {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_, span := provider.Tracer("test").Start(context.Background(), "op")
+	RecordError(span, err)
+	span.End()
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	return spans[0]
+}
+
+func attrMap(attrs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(attrs))
+	for _, kv := range attrs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func Test_RecordError(t *testing.T) {
+	is := assert.New(t)
+
+	err := errors.NotFoundf("user %d", 42)
+	err = errors.Wrap(err, "get user", "user_id", int64(42), "tags", []string{"a", "b"})
+	span := recordSpan(t, err)
+
+	is.Equal(codes.Error, span.Status().Code)
+	is.Equal("get user: user 42 not found", span.Status().Description)
+
+	attrs := attrMap(span.Attributes())
+	is.Equal(int64(42), attrs["user_id"].AsInt64())
+	is.Equal([]string{"a", "b"}, attrs["tags"].AsStringSlice())
+
+	events := span.Events()
+	is.Len(events, 1)
+	is.Equal("exception", events[0].Name)
+	ev := attrMap(events[0].Attributes)
+	is.Equal("NotFound", ev[exceptionType].AsString())
+	is.Equal("get user: user 42 not found", ev[exceptionMessage].AsString())
+	is.True(strings.Contains(ev[exceptionStacktrace].AsString(), "Test_RecordError"))
+}
+
+func Test_RecordMultiError(t *testing.T) {
+	is := assert.New(t)
+
+	err := errors.Append(nil, io.EOF, errors.New("second"))
+	span := recordSpan(t, err)
+
+	events := span.Events()
+	is.Len(events, 2)
+	ev0 := attrMap(events[0].Attributes)
+	is.Equal("*errors.errorString", ev0[exceptionType].AsString())
+	is.Equal("EOF", ev0[exceptionMessage].AsString())
+	_, ok := ev0[exceptionStacktrace]
+	is.False(ok)
+	ev1 := attrMap(events[1].Attributes)
+	is.Equal("*errors.fundamental", ev1[exceptionType].AsString())
+	is.Equal("second", ev1[exceptionMessage].AsString())
+	is.Equal(codes.Error, span.Status().Code)
+}
+
+func Test_RecordNil(t *testing.T) {
+	span := recordSpan(t, nil)
+	assert.Equal(t, codes.Unset, span.Status().Code)
+	assert.Len(t, span.Events(), 0)
+}
+
+func Test_RecordCyclicField(t *testing.T) {
+	is := assert.New(t)
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	span := recordSpan(t, errors.New("boom", "dump", m))
+	is.Equal("map[self:<cycle>]", attrMap(span.Attributes())["dump"].AsString())
+}
@@ -0,0 +1,72 @@
+package errors
+
+// KindPublicMessages are the public messages of errors of the given kinds,
+// see KindOf, which are not annotated by WithPublicMessage.
+// It is not safe to be modified concurrently with PublicMessage, it should
+// be changed in an init function.
+var KindPublicMessages = map[string]string{
+	"Timeout":          "timeout",
+	"BadRequest":       "bad request",
+	"NotFound":         "not found",
+	"UserNotFound":     "user not found",
+	"NotSupported":     "not supported",
+	"NotValid":         "not valid",
+	"AlreadyExists":    "already exists",
+	"Unauthorized":     "unauthorized",
+	"Forbidden":        "forbidden",
+	"NotImplemented":   "not implemented",
+	"NotProvisioned":   "not provisioned",
+	"NotAssigned":      "not assigned",
+	"MethodNotAllowed": "method not allowed",
+}
+
+// withPublicMessage annotates an error with a public message, see
+// WithPublicMessage.
+type withPublicMessage struct {
+	annotation
+	msg string
+}
+
+// WithPublicMessage annotates err with a message which is safe to be shown
+// to users, e.g. the clients of an API, see PublicMessage. The message
+// returned by Error is unchanged, thus internal details, e.g. table names
+// and hosts, are still available to logs.
+//
+//     return errors.WithPublicMessage(errors.Wrapf(err, "query %s", table),
+//             "the order cannot be loaded, please try again later")
+//
+// If err is nil, WithPublicMessage returns nil.
+func WithPublicMessage(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &withPublicMessage{annotation: newAnnotation(err), msg: message}
+}
+
+// PublicMessage returns the message of err which is safe to be shown to
+// users, which is given by the outermost error in the chain annotated by
+// WithPublicMessage, or the message of the kind of err given by
+// KindPublicMessages, e.g. "not found".
+//
+// If err has neither, PublicMessage returns an empty string, the caller
+// should then use a generic message, e.g. the text of a HTTP status.
+func PublicMessage(err error) string {
+	for e := err; e != nil; e = Unwrap(e) {
+		if w, ok := e.(*withPublicMessage); ok {
+			return w.msg
+		}
+	}
+	return KindPublicMessages[KindOf(err)]
+}
+
+// HasPublicMessage tells whether an error in the chain of err is
+// annotated by WithPublicMessage, that is the public message of err is
+// not the message of its kind.
+func HasPublicMessage(err error) bool {
+	for e := err; e != nil; e = Unwrap(e) {
+		if _, ok := e.(*withPublicMessage); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPublicMessage(t *testing.T) {
+	internal := Wrap(NotFoundf("table users"), "query db-1.internal")
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{New("connect to 10.0.0.1"), ""},
+		{internal, "not found"},
+		{Timeoutf("call backend"), "timeout"},
+		{WithPublicMessage(internal, "no such user"), "no such user"},
+		{Annotate(WithPublicMessage(internal, "no such user"), "handle"), "no such user"},
+		{WithPublicMessage(WithPublicMessage(internal, "inner"), "outer"), "outer"},
+	}
+	for i, tt := range tests {
+		if got := PublicMessage(tt.err); got != tt.want {
+			t.Errorf("%d: PublicMessage: got %q, want %q", i, got, tt.want)
+		}
+	}
+	if HasPublicMessage(internal) || !HasPublicMessage(Annotate(WithPublicMessage(internal, "no such user"), "handle")) {
+		t.Errorf("HasPublicMessage: got %v", HasPublicMessage(internal))
+	}
+
+	err := WithPublicMessage(internal, "no such user")
+	if err.Error() != internal.Error() || !IsNotFound(err) || !HasStack(err) {
+		t.Errorf("WithPublicMessage: the cause is lost: %v", err)
+	}
+	if got := fmt.Sprintf("%+v", err); got != fmt.Sprintf("%+v", internal) {
+		t.Errorf("Format: got %q", got)
+	}
+	if WithPublicMessage(nil, "oops") != nil {
+		t.Errorf("WithPublicMessage(nil): got non-nil error")
+	}
+}
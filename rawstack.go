@@ -0,0 +1,193 @@
+package errors
+
+import (
+	"bytes"
+	"debug/elf"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// rawStackVersion prefixes the encoded form of a RawStack.
+const rawStackVersion = "v1"
+
+// RawStack is a compact representation of a stack trace, it holds only
+// the raw program counters and the identity of the running binary.
+// It is much cheaper to produce and log than formatted frames, the
+// frames can be recovered later by cmd/errsym using the same binary.
+//
+// The program counters are stored relative to an anchor function of this
+// package, which makes the encoding independent of the address the binary
+// was loaded at (e.g. position independent executables).
+type RawStack struct {
+	// BuildID is the Go build ID of the binary which produced the stack,
+	// it is empty if the build ID is not available.
+	BuildID string
+
+	// Anchor is the address of the anchor function in the running binary.
+	Anchor uintptr
+
+	// PCs are the program counters of the stack frames, as returned by
+	// runtime.Callers.
+	PCs []uintptr
+}
+
+// GetRawStack returns a RawStack of the first StackTracer in the error
+// chain, or nil if there is none.
+func GetRawStack(err error) *RawStack {
+	stacked := GetStackTracer(err)
+	if stacked == nil {
+		return nil
+	}
+	return NewRawStack(stacked)
+}
+
+// NewRawStack returns a RawStack of the given StackTracer.
+func NewRawStack(st StackTracer) *RawStack {
+	var pcs []uintptr
+	if s, ok := st.(*stack); ok {
+		pcs = []uintptr(*s)
+	} else {
+		trace := st.StackTrace()
+		pcs = make([]uintptr, len(trace))
+		for i, f := range trace {
+			pcs[i] = uintptr(f)
+		}
+	}
+	return &RawStack{
+		BuildID: buildID(),
+		Anchor:  anchorPC,
+		PCs:     pcs,
+	}
+}
+
+// String returns the encoded form of the stack, which looks like
+//
+//     v1:<build id>:<anchor>:<offset>,<offset>,...
+//
+// where anchor is a hexadecimal address, and each offset is the signed
+// hexadecimal distance from the anchor to a program counter.
+func (r *RawStack) String() string {
+	if r == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.Grow(len(rawStackVersion) + len(r.BuildID) + 20 + len(r.PCs)*8)
+	b.WriteString(rawStackVersion)
+	b.WriteByte(':')
+	b.WriteString(r.BuildID)
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatUint(uint64(r.Anchor), 16))
+	b.WriteByte(':')
+	for i, pc := range r.PCs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatInt(int64(pc-r.Anchor), 16))
+	}
+	return b.String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r *RawStack) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *RawStack) UnmarshalText(text []byte) error {
+	parsed, err := ParseRawStack(string(text))
+	if err != nil {
+		return err
+	}
+	*r = *parsed
+	return nil
+}
+
+// ParseRawStack parses the encoded form of a RawStack.
+func ParseRawStack(s string) (*RawStack, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 4 || parts[0] != rawStackVersion {
+		return nil, Errorf("invalid raw stack %q", s)
+	}
+	anchor, err := strconv.ParseUint(parts[2], 16, 64)
+	if err != nil {
+		return nil, Wrapf(err, "invalid raw stack anchor %q", parts[2])
+	}
+	r := &RawStack{
+		BuildID: parts[1],
+		Anchor:  uintptr(anchor),
+	}
+	if parts[3] != "" {
+		offsets := strings.Split(parts[3], ",")
+		r.PCs = make([]uintptr, len(offsets))
+		for i, x := range offsets {
+			off, err := strconv.ParseInt(x, 16, 64)
+			if err != nil {
+				return nil, Wrapf(err, "invalid raw stack offset %q", x)
+			}
+			r.PCs[i] = r.Anchor + uintptr(off)
+		}
+	}
+	return r, nil
+}
+
+// stackAnchor is never called, its address is used as a reference point
+// to encode program counters, see RawStack.
+func stackAnchor() {}
+
+var anchorPC = reflect.ValueOf(stackAnchor).Pointer()
+
+var (
+	buildIDOnce  sync.Once
+	buildIDValue string
+)
+
+// buildID returns the Go build ID of the running executable.
+func buildID() string {
+	buildIDOnce.Do(func() {
+		exe, err := os.Executable()
+		if err != nil {
+			return
+		}
+		buildIDValue, _ = ReadBuildID(exe)
+	})
+	return buildIDValue
+}
+
+// ReadBuildID reads the Go build ID from the ELF binary at path.
+func ReadBuildID(path string) (string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return "", Wrap(err, "open binary")
+	}
+	defer f.Close()
+
+	sect := f.Section(".note.go.buildid")
+	if sect == nil {
+		return "", New("build ID note not found")
+	}
+	data, err := sect.Data()
+	if err != nil {
+		return "", Wrap(err, "read build ID note")
+	}
+
+	// The note is laid out as namesz, descsz, type, name and desc,
+	// the name is "Go" padded to 4 bytes.
+	if len(data) < 16 {
+		return "", New("build ID note too short")
+	}
+	order := f.ByteOrder
+	nameSize := order.Uint32(data[0:])
+	descSize := order.Uint32(data[4:])
+	name := data[12:]
+	if nameSize != 4 || !bytes.HasPrefix(name, []byte("Go\x00\x00")) {
+		return "", New("invalid build ID note")
+	}
+	desc := data[16:]
+	if uint32(len(desc)) < descSize {
+		return "", New("build ID note truncated")
+	}
+	return string(desc[:descSize]), nil
+}
@@ -0,0 +1,39 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+
+	pkgerr "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawStack(t *testing.T) {
+	is := assert.New(t)
+	is.Nil(GetRawStack(io.EOF))
+
+	raw := GetRawStack(New("boom"))
+	is.NotNil(raw)
+	is.Equal(anchorPC, raw.Anchor)
+
+	parsed, err := ParseRawStack(raw.String())
+	is.Nil(err)
+	is.Equal(raw, parsed)
+
+	data, err := json.Marshal(map[string]*RawStack{"stack": raw})
+	is.Nil(err)
+	var decoded map[string]*RawStack
+	is.Nil(json.Unmarshal(data, &decoded))
+	is.Equal(raw, decoded["stack"])
+
+	// Stacks of other packages are accepted too.
+	other := GetRawStack(pkgerr.New("boom"))
+	is.NotNil(other)
+	is.Equal(len(GetStackTracer(pkgerr.New("boom")).StackTrace()), len(other.PCs))
+
+	for _, invalid := range []string{"", "v2:id:0:1", "v1:id:xyz:1", "v1:id:10:1,zz"} {
+		_, err := ParseRawStack(invalid)
+		is.NotNil(err, invalid)
+	}
+}
@@ -0,0 +1,177 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RedactionMark replaces unsafe values in redacted output.
+const RedactionMark = "‹×›"
+
+// SafeValue is implemented by types whose values are safe to be
+// reported, e.g. they never contain personal information, values of
+// such types are not redacted unless marked by Sensitive.
+type SafeValue interface {
+	SafeValue()
+}
+
+// Safe marks v as safe to be reported, it will not be redacted when used
+// as a message argument or a field value.
+// The returned value formats the same way as v.
+func Safe(v interface{}) interface{} {
+	return safeValue{v}
+}
+
+// Sensitive marks v as unsafe to be reported, it will always be redacted
+// when used as a message argument or a field value, even if it implements
+// SafeValue. The returned value formats the same way as v, thus it is
+// only hidden by the redacted output, e.g. Redacted.
+func Sensitive(v interface{}) interface{} {
+	return sensitiveValue{v}
+}
+
+type safeValue struct{ v interface{} }
+
+func (x safeValue) Format(s fmt.State, verb rune) { fmt.Fprintf(s, fmt.FormatString(s, verb), x.v) }
+func (x safeValue) MarshalJSON() ([]byte, error)  { return json.Marshal(x.v) }
+
+type sensitiveValue struct{ v interface{} }
+
+func (x sensitiveValue) Format(s fmt.State, verb rune) { fmt.Fprintf(s, fmt.FormatString(s, verb), x.v) }
+func (x sensitiveValue) MarshalJSON() ([]byte, error)  { return json.Marshal(x.v) }
+
+type redactionMark struct{}
+
+func (redactionMark) Format(s fmt.State, verb rune) { fmt.Fprint(s, RedactionMark) }
+
+// isSafe tells whether v is safe to be reported.
+func isSafe(v interface{}) bool {
+	switch v.(type) {
+	case safeValue:
+		return true
+	case sensitiveValue:
+		return false
+	case SafeValue:
+		return true
+	}
+	return false
+}
+
+// RedactValue returns v if it is safe to be reported, else RedactionMark.
+// Values are unsafe unless they are marked by Safe or implement SafeValue.
+func RedactValue(v interface{}) interface{} {
+	if isSafe(v) {
+		return v
+	}
+	return RedactionMark
+}
+
+// Redacted returns the message of err with unsafe parts replaced by
+// RedactionMark.
+//
+// Plain messages given to functions of this package, e.g. New, Wrap and
+// WithMessage, and format specifiers are considered safe, arguments of
+// format specifiers are redacted unless they are safe, see RedactValue.
+// Arguments which are errors are redacted recursively. Messages of errors
+// not created by this package are redacted unless the error implements
+// SafeValue.
+//
+// If err is nil, Redacted returns an empty string.
+func Redacted(err error) string {
+	if err == nil {
+		return ""
+	}
+	var b strings.Builder
+	writeRedacted(&b, err)
+	return b.String()
+}
+
+func writeRedacted(b *strings.Builder, err error) {
+	switch err := err.(type) {
+	case *fundamental:
+		b.WriteString(err.msg.redacted())
+	case *withType:
+		b.WriteString(err.msg.redacted())
+	case *withMessage:
+		b.WriteString(err.msg.redacted())
+		b.WriteString(": ")
+		writeRedacted(b, err.cause)
+	case *withStack:
+		writeRedacted(b, err.error)
+	case *withFields:
+		writeRedacted(b, err.error)
+	case annotator:
+		writeRedacted(b, Unwrap(err))
+	case *withViolations:
+		writeRedacted(b, err.error)
+	case *redactedError:
+		writeRedacted(b, err.err)
+	case ErrorGroup:
+		for i, e := range err.Errors() {
+			if i > 0 {
+				b.Write(_singlelineSeparator)
+			}
+			writeRedacted(b, e)
+		}
+	default:
+		if _, ok := err.(SafeValue); ok {
+			b.WriteString(err.Error())
+		} else {
+			b.WriteString(RedactionMark)
+		}
+	}
+}
+
+// redacted renders the message with unsafe arguments redacted.
+func (m *message) redacted() string {
+	if !m.formatted {
+		return m.text
+	}
+	args := make([]interface{}, len(m.args))
+	for i, arg := range m.args {
+		if isSafe(arg) {
+			args[i] = arg
+		} else if err, ok := arg.(error); ok {
+			args[i] = Redacted(err)
+		} else {
+			args[i] = redactionMark{}
+		}
+	}
+	return fmt.Sprintf(m.format, args...)
+}
+
+// RedactedFields returns the attached fields of err like Fields, but
+// with unsafe values replaced by RedactionMark, see RedactValue.
+func RedactedFields(err error) F {
+	fields := Fields(err)
+	if fields == nil {
+		return nil
+	}
+	out := make(F, len(fields))
+	for k, v := range fields {
+		out[k] = RedactValue(v)
+	}
+	return out
+}
+
+// RedactedError returns an error whose message is the redacted message
+// of err, see Redacted. The returned error has err as its cause, thus
+// the stack trace and fields of err are still available.
+//
+// If err is nil, RedactedError returns nil.
+func RedactedError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
+
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string  { return Redacted(e.err) }
+func (e *redactedError) Cause() error   { return e.err }
+func (e *redactedError) Unwrap() error  { return e.err }
+func (e *redactedError) HasStack() bool { return HasStack(e.err) }
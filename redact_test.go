@@ -0,0 +1,94 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type safeID int
+
+func (safeID) SafeValue() {}
+
+type safeErr struct{}
+
+func (safeErr) Error() string { return "safe error" }
+func (safeErr) SafeValue()    {}
+
+func TestRedacted(t *testing.T) {
+	is := assert.New(t)
+	is.Equal("", Redacted(nil))
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{New("plain message"), "plain message"},
+		{Errorf("user %s not found", "alice@example.com"), "user ‹×› not found"},
+		{Errorf("user %d not found", Safe(42)), "user 42 not found"},
+		{Errorf("user %d not found", safeID(42)), "user 42 not found"},
+		{Errorf("user %d not found", Sensitive(safeID(42))), "user ‹×› not found"},
+		{NotFoundf("user %q", "alice"), "user ‹×› not found"},
+		{Wrapf(io.EOF, "read %s", Safe("body")), "read body: ‹×›"},
+		{Wrap(safeErr{}, "query"), "query: safe error"},
+		{WithMessagef(New("inner"), "cause: %v", Errorf("token %s", "secret")), "cause: token ‹×›: inner"},
+		{WithFields(New("with fields"), F{"email": "a@b.c"}), "with fields"},
+		{Append(nil, io.EOF, New("second")), "‹×›; second"},
+	}
+	for _, tt := range tests {
+		is.Equal(tt.want, Redacted(tt.err))
+	}
+
+	// Marked values are formatted the same way as the original values.
+	err := Errorf("%05.1f %q %x", Safe(3.14159), Sensitive("x"), Safe(255))
+	is.Equal(`003.1 "x" ff`, err.Error())
+}
+
+func TestRedactedFields(t *testing.T) {
+	is := assert.New(t)
+	is.Nil(RedactedFields(io.EOF))
+
+	err := WithFields(io.EOF, F{"email": "a@b.c", "id": Safe(1), "sid": safeID(2)})
+	is.Equal(F{"email": RedactionMark, "id": Safe(1), "sid": safeID(2)}, RedactedFields(err))
+	is.Equal("EOF\ncontext: email=a@b.c id=1 sid=2", fmt.Sprintf("%+v", err))
+}
+
+func TestRedactedError(t *testing.T) {
+	is := assert.New(t)
+	is.Nil(RedactedError(nil))
+
+	err := WithFields(Errorf("token %s", "secret"), F{"k": "v"})
+	redacted := RedactedError(err)
+	is.Equal("token ‹×›", redacted.Error())
+	is.True(HasStack(redacted))
+	is.NotNil(GetStackTracer(redacted))
+	is.Equal(F{"k": "v"}, Fields(redacted))
+}
+
+func TestMarshalRedactedJSON(t *testing.T) {
+	is := assert.New(t)
+	err := WithFields(Wrapf(io.EOF, "read %s of %s", Safe("body"), "alice"), F{"email": "a@b.c", "n": Safe(1)})
+	data, jerr := MarshalRedactedJSON(err)
+	is.Nil(jerr)
+	is.NotContains(string(data), "alice")
+	is.NotContains(string(data), "a@b.c")
+	is.NotContains(string(data), "EOF")
+
+	var got struct {
+		Message string
+		Fields  map[string]interface{}
+		Chain   []struct {
+			Message string
+			Args    []interface{}
+		}
+	}
+	is.Nil(json.Unmarshal(data, &got))
+	is.Equal("read body of ‹×›: ‹×›", got.Message)
+	is.Equal(map[string]interface{}{"email": RedactionMark, "n": float64(1)}, got.Fields)
+	is.Equal("read body of ‹×›", got.Chain[2].Message)
+	is.Equal([]interface{}{"body", RedactionMark}, got.Chain[2].Args)
+	is.Equal(RedactionMark, got.Chain[3].Message)
+}
@@ -0,0 +1,169 @@
+// Package retry retries operations which fail with retryable errors,
+// see errors.IsRetryable.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/jxskiss/errors"
+)
+
+// DefaultPolicy is used by Do for the zero values of a Policy.
+var DefaultPolicy = Policy{
+	MaxAttempts:  3,
+	InitialDelay: 100 * time.Millisecond,
+	MaxDelay:     10 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// Policy tells how an operation is retried.
+//
+// The delay before the n-th retry is InitialDelay * Multiplier^(n-1),
+// but at most MaxDelay, reduced by a random fraction up to Jitter.
+// If the error tells a longer delay, see errors.RetryAfter, the operation
+// is retried after that delay instead.
+type Policy struct {
+	// MaxAttempts is the maximum number of calls of the operation.
+	MaxAttempts int
+
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+
+	// MaxDelay is the maximum delay computed by backing off, a negative
+	// value means unbounded. It does not limit the delay told by an error.
+	MaxDelay time.Duration
+
+	// Multiplier is the factor the delay grows by for each retry.
+	Multiplier float64
+
+	// Jitter is the maximum fraction the delay is randomly reduced by,
+	// from 0 to 1, which spreads the retries of concurrent callers.
+	// A negative value disables jitter.
+	Jitter float64
+
+	// Retryable tells whether an error is retried, if it is nil,
+	// errors which are retryable by errors.IsRetryable, or have no kind,
+	// see errors.KindOf, are retried. Errors of other kinds, e.g.
+	// "NotFound" and "BadRequest", are not.
+	Retryable func(err error) bool
+
+	// Clock is used to wait between attempts, if it is nil, the system
+	// clock is used.
+	Clock Clock
+}
+
+// Clock tells the current time and waits, it is used to test retries
+// without waiting.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Do calls fn until it succeeds, it fails with an error which is not
+// retryable, MaxAttempts is reached, or ctx is done.
+// The zero values of the fields of policy, except Retryable and Clock,
+// are replaced by those of DefaultPolicy.
+//
+// If fn fails, Do returns an errors.MultiError which holds the error of
+// each attempt, with the fields "attempt", starting from 1, and "elapsed",
+// the time since Do was called. If ctx is done while waiting, the error
+// of ctx is the last one. Use Last to get the last error.
+func Do(ctx context.Context, fn func(ctx context.Context) error, policy Policy) error {
+	p := policy.withDefaults()
+	start := p.Clock.Now()
+	var errs errors.MultiError
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return append(errs, p.annotate(err, attempt, start))
+		}
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, p.annotate(err, attempt, start))
+		if attempt >= p.MaxAttempts || !p.retryable(err) {
+			return errs
+		}
+		select {
+		case <-ctx.Done():
+			return append(errs, p.annotate(ctx.Err(), attempt+1, start))
+		case <-p.Clock.After(p.delay(attempt, err)):
+		}
+	}
+}
+
+// Last returns the last error of err if it is returned by Do, else err.
+func Last(err error) error {
+	if errs, ok := err.(errors.MultiError); ok && len(errs) > 0 {
+		return errs[len(errs)-1]
+	}
+	return err
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultPolicy.MaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultPolicy.InitialDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = DefaultPolicy.MaxDelay
+	}
+	if p.Multiplier <= 0 {
+		p.Multiplier = DefaultPolicy.Multiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = DefaultPolicy.Jitter
+	}
+	if p.Clock == nil {
+		p.Clock = systemClock{}
+	}
+	return p
+}
+
+func (p Policy) annotate(err error, attempt int, start time.Time) error {
+	return errors.WithFieldList(err,
+		errors.Int("attempt", attempt),
+		errors.Duration("elapsed", p.Clock.Now().Sub(start)))
+}
+
+func (p Policy) retryable(err error) bool {
+	if errors.Cause(err) == context.Canceled {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return errors.IsRetryable(err) || errors.KindOf(err) == ""
+}
+
+// delay returns the delay after the given attempt failed with err.
+func (p Policy) delay(attempt int, err error) time.Duration {
+	d := float64(p.InitialDelay)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+		if p.MaxDelay > 0 && d >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d -= d * p.Jitter * rand.Float64()
+	}
+	delay := time.Duration(d)
+	if after := errors.RetryAfter(err); after > delay {
+		delay = after
+	}
+	return delay
+}
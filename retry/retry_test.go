@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock advances by the waited durations instantly.
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func failing(errs ...error) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls > len(errs) {
+			return nil
+		}
+		return errs[calls-1]
+	}, &calls
+}
+
+func Test_Do(t *testing.T) {
+	is := assert.New(t)
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	policy := Policy{MaxAttempts: 5, InitialDelay: time.Second, MaxDelay: 3 * time.Second, Jitter: -1, Clock: clock}
+
+	fn, calls := failing(errors.New("e1"), errors.Timeoutf("e2"), errors.New("e3"), errors.New("e4"))
+	is.Nil(Do(context.Background(), fn, policy))
+	is.Equal(5, *calls)
+	is.Equal([]time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}, clock.waits)
+
+	clock.waits = nil
+	fn, calls = failing(errors.New("e1"), errors.New("e2"), errors.New("e3"))
+	err := Do(context.Background(), fn, Policy{MaxAttempts: 2, Jitter: -1, Clock: clock})
+	is.Equal(2, *calls)
+	errs, ok := err.(errors.MultiError)
+	is.True(ok)
+	is.Len(errs, 2)
+	is.Equal("e2", Last(err).Error())
+	is.Equal(1, errors.Fields(errs[0])["attempt"])
+	is.Equal(time.Duration(0), errors.Fields(errs[0])["elapsed"])
+	is.Equal(2, errors.Fields(errs[1])["attempt"])
+	is.Equal(100*time.Millisecond, errors.Fields(errs[1])["elapsed"])
+}
+
+func Test_DoNotRetryable(t *testing.T) {
+	is := assert.New(t)
+	clock := &fakeClock{}
+
+	fn, calls := failing(errors.New("e1"), errors.NotFoundf("user"), errors.New("e3"))
+	err := Do(context.Background(), fn, Policy{MaxAttempts: 5, Clock: clock})
+	is.Equal(2, *calls)
+	is.True(errors.IsNotFound(Last(err)))
+
+	// A retryable mark takes precedence over the kind.
+	fn, calls = failing(errors.Retryable(errors.NotFoundf("user"), 0))
+	is.Nil(Do(context.Background(), fn, Policy{Clock: clock}))
+	is.Equal(2, *calls)
+
+	fn, calls = failing(errors.Wrap(context.Canceled, "call"))
+	Do(context.Background(), fn, Policy{Clock: clock})
+	is.Equal(1, *calls)
+
+	fn, calls = failing(errors.New("e1"), errors.New("e2"))
+	Do(context.Background(), fn, Policy{
+		Clock:     clock,
+		Retryable: func(err error) bool { return false },
+	})
+	is.Equal(1, *calls)
+}
+
+func Test_DoRetryAfter(t *testing.T) {
+	is := assert.New(t)
+	clock := &fakeClock{}
+
+	fn, _ := failing(errors.Retryable(errors.New("throttled"), 30*time.Second), errors.New("e2"))
+	is.Nil(Do(context.Background(), fn, Policy{MaxAttempts: 3, MaxDelay: time.Second, Jitter: -1, Clock: clock}))
+	is.Equal([]time.Duration{30 * time.Second, 200 * time.Millisecond}, clock.waits)
+}
+
+func Test_DoJitter(t *testing.T) {
+	is := assert.New(t)
+	clock := &fakeClock{}
+
+	fn, _ := failing(errors.New("e1"), errors.New("e2"), errors.New("e3"))
+	is.Nil(Do(context.Background(), fn, Policy{MaxAttempts: 4, InitialDelay: time.Second, Jitter: 0.5, Clock: clock}))
+	is.Len(clock.waits, 3)
+	for i, d := range clock.waits {
+		max := time.Second << i
+		is.True(d > max/2 && d <= max, "wait %d: %v", i, d)
+	}
+}
+
+func Test_DoDefaults(t *testing.T) {
+	is := assert.New(t)
+	clock := &fakeClock{}
+
+	errs := make([]error, 10)
+	for i := range errs {
+		errs[i] = errors.New("e")
+	}
+	fn, _ := failing(errs...)
+	is.Nil(Do(context.Background(), fn, Policy{MaxAttempts: 11, Clock: clock}))
+	is.Len(clock.waits, 10)
+	for i, d := range clock.waits {
+		max := DefaultPolicy.InitialDelay << i
+		if max > DefaultPolicy.MaxDelay {
+			max = DefaultPolicy.MaxDelay
+		}
+		min := time.Duration(float64(max) * (1 - DefaultPolicy.Jitter))
+		is.True(d >= min && d <= max, "wait %d: %v", i, d)
+	}
+	is.Equal(DefaultPolicy.MaxDelay, Policy{}.withDefaults().MaxDelay)
+	is.Equal(DefaultPolicy.Jitter, Policy{}.withDefaults().Jitter)
+
+	// Negative values disable the limit and jitter.
+	clock.waits = nil
+	fn, _ = failing(errs...)
+	is.Nil(Do(context.Background(), fn, Policy{MaxAttempts: 11, MaxDelay: -1, Jitter: -1, Clock: clock}))
+	is.Equal(DefaultPolicy.InitialDelay<<9, clock.waits[9])
+}
+
+func Test_DoContext(t *testing.T) {
+	is := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	fn := func(context.Context) error {
+		cancel()
+		return errors.New("e1")
+	}
+	err := Do(ctx, fn, Policy{InitialDelay: time.Hour})
+	errs := errors.Errors(err)
+	is.Len(errs, 2)
+	is.Equal(context.Canceled, errors.Cause(Last(err)))
+	is.Equal(2, errors.Fields(Last(err))["attempt"])
+
+	fn2, calls := failing()
+	err = Do(ctx, fn2, Policy{})
+	is.Equal(0, *calls)
+	is.Equal(context.Canceled, errors.Cause(Last(err)))
+}
@@ -0,0 +1,80 @@
+package errors
+
+import (
+	"time"
+)
+
+// RetryableKinds are the kinds, see KindOf, of errors which are retryable
+// without being marked by Retryable. It is not safe to be modified
+// concurrently with IsRetryable, it should be changed in an init function.
+var RetryableKinds = map[string]bool{
+	"Timeout": true,
+}
+
+// withRetry marks an error as retryable, see Retryable.
+type withRetry struct {
+	annotation
+	after time.Duration
+}
+
+func (w *withRetry) RetryAfter() time.Duration { return w.after }
+
+// Retryable marks err as retryable, after is the delay the operation
+// should be retried after, zero tells that the delay is up to the caller,
+// e.g. a backoff policy. The message and the cause of err are kept.
+//
+// If err is nil, Retryable returns nil.
+func Retryable(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	if after < 0 {
+		after = 0
+	}
+	return &withRetry{annotation: newAnnotation(err), after: after}
+}
+
+// retryAfterer is implemented by errors which tell when to retry,
+// e.g. errors marked by Retryable, and errors received from gRPC
+// servers by package grpc_ext.
+type retryAfterer interface {
+	RetryAfter() time.Duration
+}
+
+// IsRetryable tells whether err is retryable, that is an error in the
+// chain of err is marked by Retryable, or has a method
+// "RetryAfter() time.Duration" which returns a positive duration,
+// or the kind of err is one of RetryableKinds, e.g. "Timeout".
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, ok := retryAfter(err); ok {
+		return true
+	}
+	return RetryableKinds[KindOf(err)]
+}
+
+// RetryAfter returns the delay which err tells to retry after, see
+// Retryable, or zero if it does not tell one.
+func RetryAfter(err error) time.Duration {
+	after, _ := retryAfter(err)
+	return after
+}
+
+// retryAfter returns the delay of the outermost error in the chain of err
+// which is marked by Retryable, or has a RetryAfter method returning
+// a positive duration.
+func retryAfter(err error) (time.Duration, bool) {
+	for e := err; e != nil; e = Unwrap(e) {
+		switch e := e.(type) {
+		case *withRetry:
+			return e.after, true
+		case retryAfterer:
+			if after := e.RetryAfter(); after > 0 {
+				return after, true
+			}
+		}
+	}
+	return 0, false
+}
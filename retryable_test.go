@@ -0,0 +1,56 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type throttledError struct{ after time.Duration }
+
+func (e throttledError) Error() string             { return "throttled" }
+func (e throttledError) RetryAfter() time.Duration { return e.after }
+
+func TestRetryable(t *testing.T) {
+	if Retryable(nil, time.Second) != nil {
+		t.Errorf("Retryable(nil): got non-nil error")
+	}
+
+	err := Retryable(Wrap(NotFoundf("user"), "get user"), 3*time.Second)
+	if err.Error() != "get user: user not found" {
+		t.Errorf("Error: got %q", err.Error())
+	}
+	if !IsNotFound(err) || !HasStack(err) {
+		t.Errorf("Retryable: the cause is lost")
+	}
+	if got := fmt.Sprintf("%+v", err); got != fmt.Sprintf("%+v", Unwrap(err)) {
+		t.Errorf("Format: got %q", got)
+	}
+
+	tests := []struct {
+		err       error
+		retryable bool
+		after     time.Duration
+	}{
+		{nil, false, 0},
+		{New("plain"), false, 0},
+		{NotFoundf("user"), false, 0},
+		{Timeoutf("call"), true, 0},
+		{Annotate(Timeoutf("call"), "get user"), true, 0},
+		{Retryable(New("plain"), 0), true, 0},
+		{err, true, 3 * time.Second},
+		{Annotate(err, "handle"), true, 3 * time.Second},
+		{Retryable(err, time.Second), true, time.Second},
+		{throttledError{2 * time.Second}, true, 2 * time.Second},
+		{throttledError{0}, false, 0},
+		{Retryable(throttledError{2 * time.Second}, 0), true, 0},
+	}
+	for i, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.retryable {
+			t.Errorf("%d: IsRetryable: got %v, want %v", i, got, tt.retryable)
+		}
+		if got := RetryAfter(tt.err); got != tt.after {
+			t.Errorf("%d: RetryAfter: got %v, want %v", i, got, tt.after)
+		}
+	}
+}
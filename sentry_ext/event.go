@@ -0,0 +1,200 @@
+// Package sentry_ext converts errors created by the errors package to
+// sentry events.
+package sentry_ext
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jxskiss/errors"
+)
+
+// maxTagValueLen is the maximum length of tag values accepted by sentry.
+const maxTagValueLen = 200
+
+// truncatedMark is appended by errors.FormatValue to truncated values.
+const truncatedMark = "..."
+
+// NewEventBuilder returns a new EventBuilder, fields attached to errors
+// whose keys match one of tagKeys are reported as tags of the events,
+// other fields are reported as extra data.
+//
+// tagKeys are key names, or patterns in the syntax of path.Match,
+// e.g. "*_id", they are matched case-insensitively.
+//   builder := NewEventBuilder("tenant", "*_id")
+//   builder.Capture(sentry.CurrentHub(), err)
+func NewEventBuilder(tagKeys ...string) *EventBuilder {
+	return &EventBuilder{TagKeys: tagKeys, Level: sentry.LevelError}
+}
+
+// EventBuilder builds sentry events from errors.
+type EventBuilder struct {
+	TagKeys []string
+	Level   sentry.Level
+}
+
+// NewEvent builds an event from err by an EventBuilder without tag keys.
+func NewEvent(err error) *sentry.Event {
+	return NewEventBuilder().Build(err)
+}
+
+// Capture builds an event from err, and captures it by hub.
+// If err is nil, Capture does nothing and returns nil.
+func (b *EventBuilder) Capture(hub *sentry.Hub, err error) *sentry.EventID {
+	if err == nil {
+		return nil
+	}
+	return hub.CaptureEvent(b.Build(err))
+}
+
+// Build builds an event from err.
+//
+// The error chain is split into one exception entry for each layer which
+// records a stack trace, e.g. an error created by New and annotated later
+// by WithStack in another goroutine results in two entries, each with its
+// own stack trace. Layers without a stack trace, e.g. messages added by
+// WithMessage, belong to the entry of the next layer with a stack trace
+// in the chain. Members of an errors.ErrorGroup are reported as entries
+// of an exception group.
+//
+// The fields attached to err, see errors.Fields, are reported as tags or
+// extra data, and the fingerprint of the event is errors.Fingerprint.
+// Tag values are rendered by errors.FormatValue and truncated to the
+// length accepted by sentry. Extra values are encoded as JSON, or
+// rendered by errors.FormatValue if they cannot be encoded or the
+// encoding is longer than errors.MaxFieldValueLen.
+func (b *EventBuilder) Build(err error) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Level = b.Level
+	if err == nil {
+		return event
+	}
+	var exceptions []sentry.Exception
+	appendExceptions(&exceptions, err, nil, "")
+	// Sentry expects the outermost exception to be the last one.
+	for i, j := 0, len(exceptions)-1; i < j; i, j = i+1, j-1 {
+		exceptions[i], exceptions[j] = exceptions[j], exceptions[i]
+	}
+	event.Exception = exceptions
+	event.Fingerprint = []string{errors.Fingerprint(err)}
+
+	for k, v := range errors.Fields(err) {
+		if b.isTag(k) {
+			event.Tags[k] = errors.FormatValue(v, maxTagValueLen-len(truncatedMark))
+		} else {
+			event.Extra[k] = extraValue(v)
+		}
+	}
+	return event
+}
+
+// extraValue returns the JSON encoding of v if it succeeds and is not
+// longer than errors.MaxFieldValueLen, else v rendered by
+// errors.FormatValue.
+func extraValue(v interface{}) interface{} {
+	if s, ok := v.(string); ok && (errors.MaxFieldValueLen <= 0 || len(s) <= errors.MaxFieldValueLen) {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil || (errors.MaxFieldValueLen > 0 && len(data) > errors.MaxFieldValueLen) {
+		return errors.FormatValue(v, errors.MaxFieldValueLen)
+	}
+	return json.RawMessage(data)
+}
+
+func (b *EventBuilder) isTag(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range b.TagKeys {
+		pattern = strings.ToLower(pattern)
+		if pattern == key {
+			return true
+		}
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// segment is a part of an error chain which is reported as one
+// exception entry.
+type segment struct {
+	err   error // the outermost error of the segment
+	stack error // the layer which records the stack trace
+	group errors.ErrorGroup
+}
+
+// splitChain splits the chain of err into segments, a new segment starts
+// at each layer with a stack trace, unless the current segment does not
+// have a stack trace yet.
+func splitChain(err error) []*segment {
+	seg := &segment{err: err}
+	segments := []*segment{seg}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if hasOwnStack(e) {
+			if seg.stack != nil {
+				seg = &segment{err: e}
+				segments = append(segments, seg)
+			}
+			seg.stack = e
+		}
+		if g, ok := e.(errors.ErrorGroup); ok && seg.group == nil {
+			seg.group = g
+		}
+	}
+	return segments
+}
+
+// hasOwnStack tells whether err records a stack trace by itself.
+// Wrappers which do not record a stack trace, e.g. the ones created by
+// WithMessage, do not implement errors.StackTracer.
+func hasOwnStack(err error) bool {
+	_, ok := err.(errors.StackTracer)
+	return ok
+}
+
+func appendExceptions(list *[]sentry.Exception, err error, parentID *int, source string) {
+	for _, seg := range splitChain(err) {
+		id := len(*list)
+		mechanism := &sentry.Mechanism{
+			Type:             sentry.MechanismTypeChained,
+			Source:           source,
+			ExceptionID:      id,
+			ParentID:         parentID,
+			IsExceptionGroup: seg.group != nil,
+		}
+		if parentID == nil {
+			mechanism.Type = sentry.MechanismTypeGeneric
+		}
+		exception := sentry.Exception{
+			Type:      exceptionType(seg),
+			Value:     seg.err.Error(),
+			Mechanism: mechanism,
+		}
+		if seg.stack != nil {
+			exception.Stacktrace = sentry.ExtractStacktrace(seg.stack)
+		}
+		*list = append(*list, exception)
+
+		if seg.group != nil {
+			for i, member := range seg.group.Errors() {
+				if member != nil {
+					appendExceptions(list, member, &id, fmt.Sprintf("errors[%d]", i))
+				}
+			}
+		}
+		parentID, source = &id, sentry.MechanismSourceCause
+	}
+}
+
+// exceptionType returns the kind reported by errors.KindOf, or the type
+// of the cause of the segment.
+func exceptionType(seg *segment) string {
+	if kind := errors.KindOf(seg.err); kind != "" {
+		return kind
+	}
+	return fmt.Sprintf("%T", errors.Cause(seg.err))
+}
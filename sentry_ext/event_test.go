@@ -0,0 +1,160 @@
+package sentry_ext
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *fakeTransport) Configure(sentry.ClientOptions)            {}
+func (t *fakeTransport) Flush(time.Duration) bool                  { return true }
+func (t *fakeTransport) FlushWithContext(ctx context.Context) bool { return true }
+func (t *fakeTransport) Close()                                    {}
+func (t *fakeTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func captureEvent(t *testing.T, builder *EventBuilder, err error) *sentry.Event {
+	transport := &fakeTransport{}
+	client, cerr := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	if cerr != nil {
+		t.Fatal(cerr)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+	if builder.Capture(hub, err) == nil {
+		t.Fatal("event not captured")
+	}
+	if len(transport.events) != 1 {
+		t.Fatalf("got %d events, want 1", len(transport.events))
+	}
+	return transport.events[0]
+}
+
+func lastFunction(st *sentry.Stacktrace) string {
+	if st == nil || len(st.Frames) == 0 {
+		return ""
+	}
+	return st.Frames[len(st.Frames)-1].Function
+}
+
+func newRootError() error {
+	return errors.New("root", "user_id", 42)
+}
+
+func Test_Build(t *testing.T) {
+	is := assert.New(t)
+
+	err := newRootError()
+	err = errors.WithMessage(err, "load")
+	ch := make(chan error)
+	go func() {
+		ch <- errors.WithStack(err, "tenant", "t1", "query", "select 1")
+	}()
+	err = errors.Wrap(<-ch, "handle")
+
+	event := captureEvent(t, NewEventBuilder("tenant", "*_ID"), err)
+	is.Equal(sentry.LevelError, event.Level)
+	is.Equal([]string{errors.Fingerprint(err)}, event.Fingerprint)
+	is.Equal(map[string]string{"tenant": "t1", "user_id": "42"}, event.Tags)
+	is.Equal(map[string]interface{}{"query": "select 1"}, event.Extra)
+
+	ex := event.Exception
+	is.Len(ex, 2)
+
+	// The outermost exception is the last one.
+	outer, inner := ex[1], ex[0]
+	is.Equal("handle: load: root", outer.Value)
+	is.Equal("*errors.fundamental", outer.Type)
+	is.Equal("Test_Build.func1", lastFunction(outer.Stacktrace))
+	is.Equal(sentry.MechanismTypeGeneric, outer.Mechanism.Type)
+	is.Equal(0, outer.Mechanism.ExceptionID)
+	is.Nil(outer.Mechanism.ParentID)
+
+	is.Equal("root", inner.Value)
+	is.Equal("newRootError", lastFunction(inner.Stacktrace))
+	is.Equal(sentry.MechanismTypeChained, inner.Mechanism.Type)
+	is.Equal(sentry.MechanismSourceCause, inner.Mechanism.Source)
+	is.Equal(1, inner.Mechanism.ExceptionID)
+	is.Equal(0, *inner.Mechanism.ParentID)
+}
+
+func Test_BuildSingle(t *testing.T) {
+	is := assert.New(t)
+
+	err := errors.Wrap(io.EOF, "read")
+	event := NewEvent(err)
+	is.Len(event.Exception, 1)
+	is.Equal("*errors.errorString", event.Exception[0].Type)
+	is.Equal("read: EOF", event.Exception[0].Value)
+	is.Equal("Test_BuildSingle", lastFunction(event.Exception[0].Stacktrace))
+
+	event = NewEvent(errors.NotFoundf("user %d", 1))
+	is.Equal("NotFound", event.Exception[0].Type)
+
+	event = NewEvent(nil)
+	is.Len(event.Exception, 0)
+}
+
+func Test_BuildGroup(t *testing.T) {
+	is := assert.New(t)
+
+	err := errors.Append(nil, io.EOF, errors.New("second"))
+	event := NewEvent(errors.WithMessage(err, "batch"))
+	ex := event.Exception
+	is.Len(ex, 3)
+
+	group := ex[2]
+	is.True(group.Mechanism.IsExceptionGroup)
+	is.Equal(0, group.Mechanism.ExceptionID)
+	for i, member := range []sentry.Exception{ex[1], ex[0]} {
+		is.Equal(i+1, member.Mechanism.ExceptionID)
+		is.Equal(0, *member.Mechanism.ParentID)
+	}
+	is.Equal("EOF", ex[1].Value)
+	is.Equal("errors[0]", ex[1].Mechanism.Source)
+	is.Nil(ex[1].Stacktrace)
+	is.Equal("second", ex[0].Value)
+	is.Equal("errors[1]", ex[0].Mechanism.Source)
+	is.Equal("Test_BuildGroup", lastFunction(ex[0].Stacktrace))
+}
+
+func Test_BuildCyclicTag(t *testing.T) {
+	is := assert.New(t)
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	event := NewEventBuilder("dump", "big").Build(errors.New("boom", "dump", m, "big", make([]int, 1000)))
+	is.Equal("map[self:<cycle>]", event.Tags["dump"])
+	is.Len(event.Tags["big"], maxTagValueLen)
+	is.True(strings.HasSuffix(event.Tags["big"], "..."))
+}
+
+func Test_BuildExtraValues(t *testing.T) {
+	is := assert.New(t)
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	event := NewEvent(errors.New("boom", "dump", m, "big", make([]int, 1000), "ids", []int{1, 2}))
+	is.Equal("map[self:<cycle>]", event.Extra["dump"])
+	is.Len(event.Extra["big"], errors.MaxFieldValueLen+len("..."))
+	is.Equal(json.RawMessage("[1,2]"), event.Extra["ids"])
+
+	// The event can be sent to sentry.
+	_, err := json.Marshal(event)
+	is.Nil(err)
+}
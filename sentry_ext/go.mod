@@ -0,0 +1,16 @@
+module github.com/jxskiss/errors/sentry_ext
+
+go 1.25.0
+
+require (
+	github.com/getsentry/sentry-go v0.43.0
+	github.com/jxskiss/errors v0.0.0-20261019081114-b144b098d53e
+	github.com/stretchr/testify v1.12.1
+)
+
+require (
+	github.com/pkg/errors v0.9.1 // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
+	golang.org/x/sys v0.45.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+)
@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"fmt"
+)
+
+// Severity tells how serious an error is, e.g. a warning is expected to
+// happen in normal operation and does not need attention.
+type Severity int
+
+// Severity levels, the zero Severity is not a valid level, it tells
+// that no severity is given.
+const (
+	Warning Severity = iota + 1
+	Error
+	Critical
+)
+
+var severityNames = [...]string{
+	Warning:  "warning",
+	Error:    "error",
+	Critical: "critical",
+}
+
+func (s Severity) String() string {
+	if s > 0 && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// KindSeverities are the severities of errors of the given kinds, see
+// KindOf, which are not annotated by WithSeverity. Errors of other kinds
+// and errors without a kind are of severity Error.
+// It is not safe to be modified concurrently with SeverityOf, it should
+// be changed in an init function.
+var KindSeverities = map[string]Severity{
+	"BadRequest":       Warning,
+	"NotFound":         Warning,
+	"UserNotFound":     Warning,
+	"NotSupported":     Warning,
+	"NotValid":         Warning,
+	"AlreadyExists":    Warning,
+	"Unauthorized":     Warning,
+	"Forbidden":        Warning,
+	"MethodNotAllowed": Warning,
+}
+
+// withSeverity annotates an error with a severity, see WithSeverity.
+type withSeverity struct {
+	annotation
+	severity Severity
+}
+
+// WithSeverity annotates err with the given severity, which is reported
+// by SeverityOf instead of the default severity of the kind of err,
+// while the message and the cause are kept.
+//
+// If err is nil, WithSeverity returns nil.
+// If severity is not valid, WithSeverity returns the original err.
+func WithSeverity(err error, severity Severity) error {
+	if err == nil {
+		return nil
+	}
+	if severity < Warning || severity > Critical {
+		return err
+	}
+	return &withSeverity{annotation: newAnnotation(err), severity: severity}
+}
+
+// SeverityOf returns the severity of err, which is given by the outermost
+// error in the chain annotated by WithSeverity, or the severity of the
+// kind of err given by KindSeverities, else Error.
+// If err is nil, SeverityOf returns zero.
+func SeverityOf(err error) Severity {
+	if err == nil {
+		return 0
+	}
+	for e := err; e != nil; e = Unwrap(e) {
+		if w, ok := e.(*withSeverity); ok {
+			return w.severity
+		}
+	}
+	if s, ok := KindSeverities[KindOf(err)]; ok {
+		return s
+	}
+	return Error
+}
@@ -0,0 +1,45 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Severity
+	}{
+		{nil, 0},
+		{New("plain"), Error},
+		{NotFoundf("user"), Warning},
+		{Annotate(BadRequestf("name"), "create user"), Warning},
+		{Timeoutf("call"), Error},
+		{WithSeverity(NotFoundf("config"), Critical), Critical},
+		{Annotate(WithSeverity(New("plain"), Warning), "handle"), Warning},
+		{WithSeverity(WithSeverity(New("plain"), Warning), Critical), Critical},
+		{WithSeverity(New("plain"), 0), Error},
+	}
+	for i, tt := range tests {
+		if got := SeverityOf(tt.err); got != tt.want {
+			t.Errorf("%d: SeverityOf: got %v, want %v", i, got, tt.want)
+		}
+	}
+
+	err := WithSeverity(Wrap(NotFoundf("user"), "get user"), Critical)
+	if err.Error() != "get user: user not found" || !IsNotFound(err) || !HasStack(err) {
+		t.Errorf("WithSeverity: the cause is lost: %v", err)
+	}
+	if got := fmt.Sprintf("%+v", err); got != fmt.Sprintf("%+v", Unwrap(err)) {
+		t.Errorf("Format: got %q", got)
+	}
+	if WithSeverity(nil, Warning) != nil {
+		t.Errorf("WithSeverity(nil): got non-nil error")
+	}
+	if got := Critical.String(); got != "critical" {
+		t.Errorf("String: got %q", got)
+	}
+	if got := Severity(9).String(); got != "Severity(9)" {
+		t.Errorf("String: got %q", got)
+	}
+}
@@ -0,0 +1,197 @@
+// Package sqlerr annotates the errors returned by database/sql drivers
+// with stack traces, query fingerprints and error kinds.
+package sqlerr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"time"
+
+	"github.com/jxskiss/errors"
+)
+
+// Driver wraps a driver.Driver, the errors returned by executing
+// statements and queries are annotated with a stack trace and the fields
+//
+//   - "query_fingerprint", see Fingerprint
+//   - "arg_count", the number of arguments
+//   - "args", the arguments redacted by RedactArg, if Args is true
+//
+// Unique constraint violations are of the kind "AlreadyExists", see
+// errors.IsAlreadyExists. The errors of the driver package which are
+// handled by database/sql, e.g. driver.ErrBadConn and driver.ErrSkip,
+// are returned unchanged.
+//
+// Errors returned when iterating rows are not annotated.
+//
+// Example:
+//
+//     sql.Register("postgres-annotated", sqlerr.NewDriver(&pq.Driver{}))
+//     db, err := sql.Open("postgres-annotated", dsn)
+type Driver struct {
+	// Base is the wrapped driver.
+	Base driver.Driver
+
+	// Args tells whether the redacted arguments are attached to errors.
+	Args bool
+
+	// RedactArg returns the value of an argument which is attached to
+	// errors, e.g. errors.RedactionMark for sensitive arguments.
+	// If it is nil, RedactArgByType is used.
+	RedactArg func(arg driver.NamedValue) interface{}
+}
+
+// RedactArgByType keeps the arguments which are numbers, booleans, times
+// and nil, and replaces strings and bytes, which may contain personal
+// information or secrets, by errors.RedactionMark.
+func RedactArgByType(arg driver.NamedValue) interface{} {
+	switch arg.Value.(type) {
+	case nil, int64, float64, bool, time.Time:
+		return arg.Value
+	}
+	return errors.RedactionMark
+}
+
+// NewDriver returns a Driver which wraps base.
+func NewDriver(base driver.Driver) *Driver {
+	return &Driver{Base: base}
+}
+
+// Open implements driver.Driver.
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	c, err := d.Base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, d: d}, nil
+}
+
+// OpenConnector implements driver.DriverContext.
+func (d *Driver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Base.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{Connector: c, d: d}, nil
+	}
+	return &dsnConnector{name: name, d: d}, nil
+}
+
+// Connector wraps c, the connections opened by the returned connector are
+// annotated like the ones opened by d, it can be used with sql.OpenDB.
+func (d *Driver) Connector(c driver.Connector) driver.Connector {
+	return &connector{Connector: c, d: d}
+}
+
+type connector struct {
+	driver.Connector
+	d *Driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, d: c.d}, nil
+}
+
+func (c *connector) Driver() driver.Driver { return c.d }
+
+type dsnConnector struct {
+	name string
+	d    *Driver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open(c.name) }
+func (c *dsnConnector) Driver() driver.Driver                        { return c.d }
+
+func init() {
+	errors.RegisterClassifier(classify)
+}
+
+// classify recognizes sql.ErrNoRows as "NotFound", and the errors of
+// PostgreSQL drivers with the SQLSTATE 23505 as "AlreadyExists", thus
+// errors.IsNotFound reports true for sql.ErrNoRows even if it is not
+// annotated.
+func classify(err error) string {
+	if err == sql.ErrNoRows {
+		return "NotFound"
+	}
+	if s, ok := err.(sqlState); ok && s.SQLState() == "23505" {
+		return "AlreadyExists"
+	}
+	return ""
+}
+
+// Annotate annotates an error returned by database/sql, sql.ErrNoRows is
+// annotated with a stack trace and the kind "NotFound", see
+// errors.IsNotFound, other errors are returned unchanged.
+//
+//     err := db.QueryRowContext(ctx, query, id).Scan(&user.Name)
+//     if err = sqlerr.Annotate(err); errors.IsNotFound(err) {
+//             ...
+//     }
+func Annotate(err error) error {
+	if err == sql.ErrNoRows {
+		return errors.WithKind(errors.AddStack(err), "NotFound")
+	}
+	return err
+}
+
+// annotate annotates an error returned by the driver for query.
+func (d *Driver) annotate(err error, query string, args []driver.NamedValue) error {
+	switch err {
+	case nil, driver.ErrSkip, driver.ErrBadConn, driver.ErrRemoveArgument, io.EOF:
+		return err
+	}
+	fields := []interface{}{
+		errors.String("query_fingerprint", Fingerprint(query)),
+		errors.Int("arg_count", len(args)),
+	}
+	if d.Args && len(args) > 0 {
+		redact := d.RedactArg
+		if redact == nil {
+			redact = RedactArgByType
+		}
+		redacted := make([]interface{}, len(args))
+		for i, arg := range args {
+			redacted[i] = redact(arg)
+		}
+		fields = append(fields, errors.Any("args", redacted))
+	}
+	err = errors.WithStack(err, fields...)
+	if isUniqueViolation(err) {
+		err = errors.WithKind(err, "AlreadyExists")
+	}
+	return err
+}
+
+func (d *Driver) annotateValues(err error, query string, args []driver.Value) error {
+	if err == nil {
+		return nil
+	}
+	return d.annotate(err, query, namedValues(args))
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func values(args []driver.NamedValue) ([]driver.Value, error) {
+	out := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sqlerr: driver does not support named arguments")
+		}
+		out[i] = arg.Value
+	}
+	return out, nil
+}
@@ -0,0 +1,194 @@
+package sqlerr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jxskiss/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// pgError mimics the errors of PostgreSQL drivers, e.g. *pq.Error.
+type pgError struct{ code string }
+
+func (e *pgError) Error() string    { return "pq: error " + e.code }
+func (e *pgError) SQLState() string { return e.code }
+
+// fakeDriver is an in-memory driver, the result of a statement is chosen
+// by its first word.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.HasPrefix(query, "syntax") {
+		return nil, errors.New("syntax error")
+	}
+	return fakeStmt{query}, nil
+}
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct{ query string }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.err(); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.err(); err != nil {
+		return nil, err
+	}
+	var values [][]driver.Value
+	if strings.HasPrefix(s.query, "select") {
+		values = append(values, []driver.Value{"alice"})
+	}
+	return &fakeRows{values: values}, nil
+}
+
+func (s fakeStmt) err() error {
+	switch strings.Fields(s.query)[0] {
+	case "fail":
+		return errors.New("query failed")
+	case "pq":
+		return &pgError{code: "23505"}
+	case "sqlite":
+		return errors.New("UNIQUE constraint failed: users.name")
+	case "badconn":
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+type fakeRows struct{ values [][]driver.Value }
+
+func (r *fakeRows) Columns() []string { return []string{"name"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func openDB(args bool) *sql.DB {
+	d := NewDriver(fakeDriver{})
+	d.Args = args
+	connector, _ := d.OpenConnector("")
+	return sql.OpenDB(connector)
+}
+
+func Test_Driver(t *testing.T) {
+	is := assert.New(t)
+	db := openDB(false)
+	defer db.Close()
+
+	_, err := db.Exec("insert into users values (?)", "alice")
+	is.Nil(err)
+	var name string
+	is.Nil(db.QueryRow("select name from users where id = ?", 1).Scan(&name))
+	is.Equal("alice", name)
+
+	_, err = db.Exec("fail to insert (?, ?)", "alice", 42)
+	is.NotNil(err)
+	is.Equal("query failed", err.Error())
+	is.True(errors.HasStack(err))
+	fields := errors.Fields(err)
+	is.Equal(Fingerprint("fail to insert (?, ?)"), fields["query_fingerprint"])
+	is.Equal(2, fields["arg_count"])
+	is.NotContains(fields, "args")
+	is.False(errors.IsAlreadyExists(err))
+
+	_, err = db.Exec("syntax error")
+	is.Equal("syntax error", err.Error())
+	is.Equal(0, errors.Fields(err)["arg_count"])
+
+	_, err = db.Exec("pq insert into users values ($1)", "alice")
+	is.True(errors.IsAlreadyExists(err))
+	_, ok := errors.Cause(err).(*pgError)
+	is.True(ok)
+
+	_, err = db.Query("sqlite insert into users values (?)", "alice")
+	is.True(errors.IsAlreadyExists(err))
+	is.Equal("UNIQUE constraint failed: users.name", err.Error())
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	is.Nil(err)
+	_, err = tx.Exec("pq update users set name = $1", "bob")
+	is.True(errors.IsAlreadyExists(err))
+	is.Nil(tx.Rollback())
+
+	err = db.QueryRow("empty select").Scan(&name)
+	is.Equal(sql.ErrNoRows, err)
+	err = Annotate(err)
+	is.True(errors.IsNotFound(err))
+	is.Equal(sql.ErrNoRows, errors.Cause(err))
+	is.True(errors.HasStack(err))
+	is.Nil(Annotate(nil))
+}
+
+func Test_Classify(t *testing.T) {
+	is := assert.New(t)
+	is.True(errors.IsNotFound(sql.ErrNoRows))
+	is.True(errors.IsNotFound(errors.Wrap(sql.ErrNoRows, "get user")))
+	is.True(errors.IsAlreadyExists(&pgError{code: "23505"}))
+	is.False(errors.IsAlreadyExists(&pgError{code: "23503"}))
+}
+
+func Test_Driver_Args(t *testing.T) {
+	is := assert.New(t)
+	db := openDB(true)
+	defer db.Close()
+
+	_, err := db.Exec("fail to insert (?, ?, ?)", "alice", 42, true)
+	fields := errors.Fields(err)
+	is.Equal([]interface{}{errors.RedactionMark, int64(42), true}, fields["args"])
+	is.NotContains(fmt.Sprintf("%+v", err), "alice")
+
+	// A custom policy which keeps the first argument.
+	d := NewDriver(fakeDriver{})
+	d.Args = true
+	d.RedactArg = func(arg driver.NamedValue) interface{} {
+		if arg.Ordinal == 1 {
+			return arg.Value
+		}
+		return errors.RedactionMark
+	}
+	connector, _ := d.OpenConnector("")
+	db = sql.OpenDB(connector)
+	defer db.Close()
+	_, err = db.Exec("fail to insert (?, ?)", "alice", 42)
+	is.Equal([]interface{}{"alice", errors.RedactionMark}, errors.Fields(err)["args"])
+}
+
+func Test_Driver_BadConn(t *testing.T) {
+	is := assert.New(t)
+	d := NewDriver(fakeDriver{})
+	c, err := d.Open("")
+	is.Nil(err)
+	s, err := c.Prepare("badconn")
+	is.Nil(err)
+	_, err = s.Exec(nil) //nolint:staticcheck
+	is.Equal(driver.ErrBadConn, err)
+}
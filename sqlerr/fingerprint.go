@@ -0,0 +1,68 @@
+package sqlerr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/jxskiss/errors"
+)
+
+var (
+	commentRE     = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	literalRE     = regexp.MustCompile(`'(?:[^']|'')*'|\$\d+|:\w+|@\w+|\b\d+(?:\.\d+)?\b`)
+	listRE        = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	whitespaceRE  = regexp.MustCompile(`\s+`)
+	punctuationRE = regexp.MustCompile(`\s*([(),=<>])\s*`)
+)
+
+// Normalize returns query with comments removed, literals and placeholders
+// replaced by "?", lists of values collapsed, and whitespace and case
+// normalized, thus queries which differ only in their values are the same.
+func Normalize(query string) string {
+	q := commentRE.ReplaceAllString(query, " ")
+	q = literalRE.ReplaceAllString(q, "?")
+	q = whitespaceRE.ReplaceAllString(q, " ")
+	q = punctuationRE.ReplaceAllString(q, "$1")
+	q = listRE.ReplaceAllString(q, "(?)")
+	return strings.ToLower(strings.TrimSpace(q))
+}
+
+// Fingerprint returns a short hash of the normalized query, see Normalize.
+func Fingerprint(query string) string {
+	sum := sha256.Sum256([]byte(Normalize(query)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// uniqueViolationMessages are parts of the messages of unique constraint
+// violations reported by common drivers.
+var uniqueViolationMessages = []string{
+	"duplicate key value violates unique constraint", // PostgreSQL
+	"Error 1062",               // MySQL: Error 1062 (23000): Duplicate entry
+	"UNIQUE constraint failed", // SQLite
+}
+
+// sqlState is implemented by the errors of PostgreSQL drivers, e.g.
+// *pq.Error and *pgconn.PgError.
+type sqlState interface {
+	SQLState() string
+}
+
+// isUniqueViolation tells whether err is a unique constraint violation.
+// Errors of PostgreSQL drivers are recognized by the SQLSTATE 23505, other
+// drivers by their messages.
+func isUniqueViolation(err error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if s, ok := e.(sqlState); ok && s.SQLState() == "23505" {
+			return true
+		}
+	}
+	msg := err.Error()
+	for _, m := range uniqueViolationMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package sqlerr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Normalize(t *testing.T) {
+	is := assert.New(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE id = 42", "select * from users where id=?"},
+		{"select *\n\tfrom users  where id = $1", "select * from users where id=?"},
+		{"SELECT name FROM users WHERE name = 'O''Brien' -- by name", "select name from users where name=?"},
+		{"SELECT /* hint */ 1 FROM t WHERE id IN (1, 2, 3)", "select ? from t where id in(?)"},
+		{"INSERT INTO t (a, b) VALUES (:a, @b)", "insert into t(a,b)values(?)"},
+		{"UPDATE t2 SET v = 1.5", "update t2 set v=?"},
+	}
+	for _, tt := range tests {
+		is.Equal(tt.want, Normalize(tt.query), tt.query)
+	}
+
+	is.Equal(Fingerprint("select * from users where id = 1"), Fingerprint("SELECT * FROM users WHERE id=$1"))
+	is.NotEqual(Fingerprint("select * from users"), Fingerprint("select * from groups"))
+	is.Len(Fingerprint("select 1"), 16)
+}
@@ -256,3 +256,13 @@ func TestNewStack(t *testing.T) {
 		t.Errorf("NewStack(): want: %v, got: %+v", "testing.tRunner", gotFirst)
 	}
 }
+
+func TestTypedErrorStack(t *testing.T) {
+	for _, c := range jujuAdaptorTestcases {
+		err := c.maker("test error")
+		got := fmt.Sprintf("%n", GetStackTracer(err).StackTrace()[0])
+		if got != "TestTypedErrorStack" {
+			t.Errorf("%v: stack trace starts at %s, want TestTypedErrorStack", err, got)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package errors
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// MaxFieldValueLen is the maximum number of bytes a field value is
+// rendered to in the "context:" block printed by "%+v", longer values
+// are truncated. It also bounds the JSON encoding of field values and
+// message arguments, see MarshalJSON.
+// Zero or a negative value disables the limit.
+var MaxFieldValueLen = 1024
+
+// maxValueDepth is the maximum nesting depth rendered by FormatValue.
+const maxValueDepth = 10
+
+const truncatedMark = "..."
+
+// FormatValue renders v like fmt.Sprint, but stops rendering after limit
+// bytes, and does not follow a pointer, map, or slice which references
+// itself, thus it is safe to be used on arbitrary values, e.g. to export
+// field values to a logging or tracing system.
+// If limit <= 0, the length is not limited.
+func FormatValue(v interface{}, limit int) string {
+	r := &valueRenderer{limit: limit}
+	r.render(reflect.ValueOf(v), 0)
+	if r.truncated {
+		return string(r.buf) + truncatedMark
+	}
+	return string(r.buf)
+}
+
+type valueRenderer struct {
+	buf       []byte
+	limit     int
+	truncated bool
+	visiting  map[uintptr]bool
+}
+
+func (r *valueRenderer) full() bool {
+	return r.truncated
+}
+
+func (r *valueRenderer) write(s string) {
+	if r.truncated {
+		return
+	}
+	if r.limit > 0 && len(r.buf)+len(s) > r.limit {
+		s = s[:r.limit-len(r.buf)]
+		for len(s) > 0 && !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+		r.truncated = true
+	}
+	r.buf = append(r.buf, s...)
+}
+
+// enter marks the pointer of v as being rendered, it returns false if the
+// pointer is already being rendered, i.e. the value references itself.
+func (r *valueRenderer) enter(v reflect.Value) bool {
+	ptr := v.Pointer()
+	if ptr == 0 {
+		return true
+	}
+	if r.visiting == nil {
+		r.visiting = make(map[uintptr]bool)
+	}
+	if r.visiting[ptr] {
+		return false
+	}
+	r.visiting[ptr] = true
+	return true
+}
+
+func (r *valueRenderer) leave(v reflect.Value) {
+	delete(r.visiting, v.Pointer())
+}
+
+func (r *valueRenderer) render(v reflect.Value, depth int) {
+	if r.full() {
+		return
+	}
+	if !v.IsValid() {
+		r.write("<nil>")
+		return
+	}
+	if depth > maxValueDepth {
+		r.write(truncatedMark)
+		return
+	}
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case string:
+			r.write(x)
+			return
+		case error, fmt.Stringer, fmt.Formatter:
+			if v.Kind() == reflect.Ptr && v.IsNil() {
+				r.write("<nil>")
+				return
+			}
+			r.write(fmt.Sprint(x))
+			return
+		}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		r.write(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		r.write(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		r.write(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32:
+		r.write(strconv.FormatFloat(v.Float(), 'g', -1, 32))
+	case reflect.Float64:
+		r.write(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.String:
+		r.write(v.String())
+	case reflect.Interface:
+		r.render(v.Elem(), depth+1)
+	case reflect.Ptr:
+		if v.IsNil() {
+			r.write("<nil>")
+			return
+		}
+		if !r.enter(v) {
+			r.write("<cycle>")
+			return
+		}
+		r.write("&")
+		r.render(v.Elem(), depth+1)
+		r.leave(v)
+	case reflect.Slice:
+		if !r.enter(v) {
+			r.write("<cycle>")
+			return
+		}
+		r.renderList(v, depth)
+		r.leave(v)
+	case reflect.Array:
+		r.renderList(v, depth)
+	case reflect.Map:
+		if !r.enter(v) {
+			r.write("<cycle>")
+			return
+		}
+		r.renderMap(v, depth)
+		r.leave(v)
+	case reflect.Struct:
+		r.write("{")
+		for i := 0; i < v.NumField() && !r.full(); i++ {
+			if i > 0 {
+				r.write(" ")
+			}
+			r.render(v.Field(i), depth+1)
+		}
+		r.write("}")
+	default:
+		// Chan, Func, UnsafePointer and Complex.
+		r.write(fmt.Sprint(v))
+	}
+}
+
+func (r *valueRenderer) renderList(v reflect.Value, depth int) {
+	r.write("[")
+	for i := 0; i < v.Len() && !r.full(); i++ {
+		if i > 0 {
+			r.write(" ")
+		}
+		r.render(v.Index(i), depth+1)
+	}
+	r.write("]")
+}
+
+func (r *valueRenderer) renderMap(v reflect.Value, depth int) {
+	keys := v.MapKeys()
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		kr := &valueRenderer{}
+		kr.render(k, depth+1)
+		names[i] = string(kr.buf)
+	}
+	sort.Sort(mapKeys{keys, names})
+	r.write("map[")
+	for i, k := range keys {
+		if r.full() {
+			break
+		}
+		if i > 0 {
+			r.write(" ")
+		}
+		r.write(names[i])
+		r.write(":")
+		r.render(v.MapIndex(k), depth+1)
+	}
+	r.write("]")
+}
+
+type mapKeys struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (m mapKeys) Len() int           { return len(m.keys) }
+func (m mapKeys) Less(i, j int) bool { return m.names[i] < m.names[j] }
+func (m mapKeys) Swap(i, j int) {
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+	m.names[i], m.names[j] = m.names[j], m.names[i]
+}
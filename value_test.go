@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cyclicNode struct {
+	Name string
+	Next *cyclicNode
+}
+
+type panicStringer struct{}
+
+func (*panicStringer) String() string { panic("boom") }
+
+func TestFormatValue(t *testing.T) {
+	is := assert.New(t)
+
+	for _, v := range []interface{}{
+		nil, 1, int8(-2), uint16(3), 1.5, float32(0.1), true, "a b",
+		[]int{1, 2, 3}, [2]string{"x", "y"}, map[string]int{"b": 2, "a": 1},
+		struct {
+			A int
+			b string
+		}{1, "x"},
+		&struct{ A []int }{[]int{1}},
+		time.Second, io.EOF, []error{io.EOF},
+		map[int]interface{}{2: nil, 1: "x"},
+	} {
+		is.Equal(fmt.Sprint(v), FormatValue(v, 0), "%#v", v)
+	}
+
+	m := map[string]interface{}{"k": 1}
+	m["self"] = m
+	is.Equal("map[k:1 self:<cycle>]", FormatValue(m, 0))
+
+	s := make([]interface{}, 2)
+	s[0] = 1
+	s[1] = s
+	is.Equal("[1 <cycle>]", FormatValue(s, 0))
+
+	n := &cyclicNode{Name: "a"}
+	n.Next = &cyclicNode{Name: "b", Next: n}
+	is.Equal("&{a &{b <cycle>}}", FormatValue(n, 0))
+
+	// The same pointer twice is not a cycle.
+	leaf := &cyclicNode{Name: "leaf"}
+	is.Equal("[&{leaf <nil>} &{leaf <nil>}]", FormatValue([]*cyclicNode{leaf, leaf}, 0))
+
+	is.Equal(fmt.Sprint(&panicStringer{}), FormatValue(&panicStringer{}, 0))
+
+	big := make([]int, 10000)
+	is.Equal("[0 0 0"+truncatedMark, FormatValue(big, 6))
+	is.Equal("日本"+truncatedMark, FormatValue("日本語", 8))
+}
+
+func TestFieldValueLimits(t *testing.T) {
+	is := assert.New(t)
+
+	old := MaxFieldValueLen
+	defer func() { MaxFieldValueLen = old }()
+	MaxFieldValueLen = 20
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	err := With(io.EOF, "big", make([]int, 100000), "cyclic", m)
+	out := fmt.Sprintf("%+v", err)
+	is.Equal(`EOF`+"\n"+`context: big="[0 0 0 0 0 0 0 0 0 0..." cyclic="map[self:<cycle>]"`, out)
+
+	data, jerr := MarshalJSON(err)
+	is.Nil(jerr)
+	is.Contains(string(data), `"fields":{"big":"[0 0 0 0 0 0 0 0 0 0...","cyclic":"map[self:\u003ccycle\u003e]"}`)
+
+	// Small values are encoded as JSON.
+	data, _ = MarshalJSON(With(io.EOF, "small", []int{1, 2}))
+	is.Contains(string(data), `"fields":{"small":[1,2]}`)
+}
+
+func TestLazyField(t *testing.T) {
+	is := assert.New(t)
+
+	calls := 0
+	dump := func() interface{} {
+		calls++
+		return strings.Repeat("x", 3)
+	}
+	err := With(io.EOF, "dump", dump)
+	err = WithFields(err, F{"lazy": func() interface{} { return 42 }})
+	err = WithFieldList(err, Lazy("panic", func() interface{} { panic("boom") }))
+	is.Equal(0, calls)
+
+	is.Equal("EOF\ncontext: dump=xxx\ncontext: lazy=42\ncontext: panic=\"!PANIC(boom)\"", fmt.Sprintf("%+v", err))
+	is.Equal(F{"dump": "xxx", "lazy": 42, "panic": "!PANIC(boom)"}, Fields(err))
+	is.Equal(1, calls)
+}
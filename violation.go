@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// FieldViolation describes an invalid field of a request, e.g. a missing
+// or malformed value.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+func (v FieldViolation) String() string {
+	return v.Field + ": " + v.Description
+}
+
+// withViolations holds the violations attached by a single call of
+// WithViolations.
+type withViolations struct {
+	error
+	violations    []FieldViolation
+	causeHasStack bool
+}
+
+func (w *withViolations) Cause() error   { return w.error }
+func (w *withViolations) Unwrap() error  { return w.error }
+func (w *withViolations) HasStack() bool { return w.causeHasStack }
+
+func (w *withViolations) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", w.Cause())
+			for _, v := range w.violations {
+				fmt.Fprintf(s, "\nviolation: %s", v)
+			}
+			return
+		}
+		fallthrough
+	case 's', 'q':
+		io.WriteString(s, w.Error())
+	}
+}
+
+// WithViolations attaches field violations to err, which tell the invalid
+// fields of a request, e.g.
+//
+//     return errors.WithViolations(errors.BadRequestf("create user"),
+//             errors.FieldViolation{Field: "email", Description: "must not be empty"})
+//
+// The message of err is unchanged, the violations are printed by "%+v".
+// If err is nil, WithViolations returns nil.
+// If len(violations) == 0, WithViolations returns the original err.
+func WithViolations(err error, violations ...FieldViolation) error {
+	if err == nil {
+		return nil
+	}
+	if len(violations) == 0 {
+		return err
+	}
+	return &withViolations{
+		error:         err,
+		violations:    append([]FieldViolation(nil), violations...),
+		causeHasStack: HasStack(err),
+	}
+}
+
+// Violations returns the field violations attached to the error chain of
+// err, from the outermost layer to the innermost one, or nil if there is
+// none.
+func Violations(err error) []FieldViolation {
+	var violations []FieldViolation
+	for e := err; e != nil; e = Unwrap(e) {
+		if w, ok := e.(*withViolations); ok {
+			violations = append(violations, w.violations...)
+		}
+	}
+	return violations
+}
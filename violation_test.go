@@ -0,0 +1,41 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithViolations(t *testing.T) {
+	is := assert.New(t)
+
+	is.Nil(WithViolations(nil, FieldViolation{"a", "b"}))
+	is.Equal(io.EOF, WithViolations(io.EOF))
+	is.Nil(Violations(io.EOF))
+
+	email := FieldViolation{Field: "email", Description: "must not be empty"}
+	age := FieldViolation{Field: "age", Description: "must be positive"}
+	err := WithViolations(io.EOF, email)
+	err = Wrap(err, "create user")
+	err = WithViolations(err, age)
+
+	is.Equal("create user: EOF", err.Error())
+	is.Equal(io.EOF, Cause(err))
+	is.True(HasStack(err))
+	is.Equal([]FieldViolation{age, email}, Violations(err))
+	is.Equal("create user: ‹×›", Redacted(err))
+
+	out := fmt.Sprintf("%+v", WithViolations(io.EOF, email, age))
+	is.Equal("EOF\nviolation: email: must not be empty\nviolation: age: must be positive", out)
+
+	data, jerr := MarshalJSON(err)
+	is.Nil(jerr)
+	var decoded struct {
+		Violations []FieldViolation
+	}
+	is.Nil(json.Unmarshal(data, &decoded))
+	is.Equal([]FieldViolation{age, email}, decoded.Violations)
+}